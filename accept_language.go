@@ -0,0 +1,41 @@
+package echomiddleware
+
+import (
+	"strconv"
+	"strings"
+)
+
+// primaryLanguage returns the language tag with the highest quality in an Accept-Language header,
+// preferring the earliest tag on ties. The wildcard and tags with q=0 are never returned.
+func primaryLanguage(header string) string {
+	var (
+		primary string
+		best    float64
+	)
+	for _, entry := range strings.Split(header, ",") {
+		params := strings.Split(entry, ";")
+		tag := strings.TrimSpace(params[0])
+		if tag == "" || tag == "*" {
+			continue
+		}
+		quality, ok := languageQuality(params[1:])
+		if ok && quality > best {
+			primary, best = tag, quality
+		}
+	}
+	return primary
+}
+
+// languageQuality returns the q parameter among params, matched case-insensitively with optional
+// whitespace around the =, defaulting to 1. A malformed q value reports false.
+func languageQuality(params []string) (float64, bool) {
+	for _, param := range params {
+		name, value, _ := strings.Cut(param, "=")
+		if !strings.EqualFold(strings.TrimSpace(name), "q") {
+			continue
+		}
+		quality, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		return quality, err == nil
+	}
+	return 1, true
+}
@@ -0,0 +1,55 @@
+package echomiddleware
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestZapLoggerLogsAcceptLanguage(t *testing.T) {
+	tests := []struct {
+		name     string
+		parse    bool
+		expected string
+	}{
+		{name: "raw", expected: "fr-CH;q=0.8, en-US, th;q=0.9"},
+		{name: "primary", parse: true, expected: "en-US"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, c, _ := newTestContext(t, http.MethodGet, "/test/123", "")
+			c.Request().Header.Set("Accept-Language", "fr-CH;q=0.8, en-US, th;q=0.9")
+
+			core, obs := observer.New(zapcore.InfoLevel)
+			middleware := ZapLoggerWithConfig(ZapLoggerConfig{
+				Logger:              zap.New(core),
+				LogAcceptLanguage:   true,
+				ParseAcceptLanguage: tc.parse,
+			})
+			handler := middleware(func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			})
+			require.NoError(t, handler(c))
+
+			assert.Equal(t, tc.expected, obs.All()[0].ContextMap()["accept_language"])
+		})
+	}
+}
+
+func TestPrimaryLanguage(t *testing.T) {
+	assert.Equal(t, "th", primaryLanguage("th;q=0.9, en;q=0.9"))
+	assert.Equal(t, "de", primaryLanguage("*, de;q=0.5"))
+	assert.Equal(t, "", primaryLanguage("en;q=0, *"))
+	assert.Equal(t, "ja", primaryLanguage("en;q=bad, ja;q=0.1"))
+	assert.Equal(t, "", primaryLanguage(""))
+	assert.Equal(t, "th", primaryLanguage("en;level=1;q=0.1, th;q=0.5"))
+	assert.Equal(t, "th", primaryLanguage("en;Q=0.5, th;q=0.9"))
+	assert.Equal(t, "th", primaryLanguage("en ; q = 0.5, th"))
+	assert.Equal(t, "", primaryLanguage("en;Q=0"))
+}
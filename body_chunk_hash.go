@@ -0,0 +1,53 @@
+package echomiddleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+)
+
+// defaultBodyChunkHashSize is the chunk size of body_chunk_hashes when BodyChunkHashSize is unset
+const defaultBodyChunkHashSize = 1 << 20
+
+// chunkHashReader hashes a request body in fixed-size chunks as the handler reads it, so the body
+// is never buffered. Only the bytes the handler reads are hashed.
+type chunkHashReader struct {
+	io.ReadCloser
+	size   int
+	hash   hash.Hash
+	filled int
+	sums   []string
+}
+
+func newChunkHashReader(body io.ReadCloser, size int) *chunkHashReader {
+	return &chunkHashReader{ReadCloser: body, size: size, hash: sha256.New()}
+}
+
+func (r *chunkHashReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	for data := p[:n]; len(data) > 0; {
+		take := r.size - r.filled
+		if take > len(data) {
+			take = len(data)
+		}
+		r.hash.Write(data[:take])
+		r.filled += take
+		data = data[take:]
+		if r.filled == r.size {
+			r.sums = append(r.sums, hex.EncodeToString(r.hash.Sum(nil)))
+			r.hash.Reset()
+			r.filled = 0
+		}
+	}
+	return n, err
+}
+
+// chunkHashes returns the hex SHA-256 of every chunk read so far, the last one possibly partial
+func (r *chunkHashReader) chunkHashes() []string {
+	sums := append([]string(nil), r.sums...)
+	if r.filled > 0 {
+		sums = append(sums, hex.EncodeToString(r.hash.Sum(nil)))
+	}
+	return sums
+}
@@ -0,0 +1,82 @@
+package echomiddleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	otellog "go.opentelemetry.io/otel/log"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestZapLoggerLogsBodyChunkHashes(t *testing.T) {
+	core, obs := observer.New(zapcore.InfoLevel)
+	sink := NewRingBufferSink(2)
+	otelLogger := &recordingOTelLogger{}
+
+	e := echo.New()
+	e.Use(ZapLoggerWithConfig(ZapLoggerConfig{
+		Logger:              zap.New(core),
+		Sink:                sink,
+		SyncInsert:          true,
+		OTelLogger:          otelLogger,
+		BodyChunkHashRoutes: []string{"/uploads"},
+		BodyChunkHashSize:   4,
+	}))
+	e.POST("/uploads", func(c echo.Context) error {
+		body, err := io.ReadAll(c.Request().Body)
+		if err != nil {
+			return err
+		}
+		return c.String(http.StatusCreated, string(body))
+	})
+	e.POST("/notes", func(c echo.Context) error {
+		return c.NoContent(http.StatusCreated)
+	})
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/uploads", strings.NewReader("aaaabbbbcc")))
+	assert.Equal(t, "aaaabbbbcc", rec.Body.String())
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/notes", strings.NewReader("note")))
+
+	entries := obs.All()
+	require.Len(t, entries, 2)
+	hashes := []interface{}{sha256Hex("aaaa"), sha256Hex("bbbb"), sha256Hex("cc")}
+	upload := entries[0].ContextMap()
+	assert.Equal(t, hashes, upload["body_chunk_hashes"])
+	documents := sink.Snapshot()
+	require.Len(t, documents, 2)
+	assert.Equal(t, hashes, documents[0]["body_chunk_hashes"])
+	attribute := recordAttributes(otelLogger.records[0].record)["body_chunk_hashes"]
+	require.Equal(t, otellog.KindSlice, attribute.Kind())
+	assert.Len(t, attribute.AsSlice(), 3)
+	assert.Equal(t, sha256Hex("aaaa"), attribute.AsSlice()[0].AsString())
+	assert.Equal(t, "", upload["body"])
+	assert.NotContains(t, entries[1].ContextMap(), "body_chunk_hashes")
+	assert.Equal(t, "note", entries[1].ContextMap()["body"])
+}
+
+func TestChunkHashReaderSplitsAcrossReads(t *testing.T) {
+	reader := newChunkHashReader(io.NopCloser(strings.NewReader("abcdefgh")), 3)
+	buf := make([]byte, 2)
+	for {
+		if _, err := reader.Read(buf); err == io.EOF {
+			break
+		}
+	}
+	assert.Equal(t, []string{sha256Hex("abc"), sha256Hex("def"), sha256Hex("gh")}, reader.chunkHashes())
+}
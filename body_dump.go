@@ -3,37 +3,75 @@ package echomiddleware
 import (
 	"encoding/json"
 	"fmt"
+	"regexp"
 	"strings"
 
 	"github.com/labstack/echo/v4"
-	"github.com/spf13/viper"
 	"go.uber.org/zap"
 )
 
+// BodyDumpConfig defines the config for BodyDumpWithConfig.
+type BodyDumpConfig struct {
+	// Logger receives the body dumps. Defaults to the global logger at the time of each dump.
+	Logger *zap.Logger
+}
+
 func BodyDump(c echo.Context, reqBody, resBody []byte) {
-	if (viper.GetString("ENVIRONMENT") != "production") && c.Path() != "/healthz" {
-
-		reqBodyString := string(reqBody)
-		reqBodyString = strings.ReplaceAll(reqBodyString, "\n", "")
-		reqBodyString = strings.ReplaceAll(reqBodyString, "\r", "")
-		reqBodyString = strings.ReplaceAll(reqBodyString, "\t", "")
-
-		resBodyString := string(resBody)
-		resBodyString = strings.ReplaceAll(resBodyString, "\n", "")
-		resBodyString = strings.ReplaceAll(resBodyString, "\r", "")
-		resBodyString = strings.ReplaceAll(resBodyString, "\t", "")
-
-		j, _ := json.Marshal(BodyDumpModel{
-			Host:          c.Request().Host,
-			Path:          c.Path(),
-			Method:        c.Request().Method,
-			RemoteAddress: c.Request().RemoteAddr,
-			Header:        fmt.Sprintf("%v", c.Request().Header),
-			Status:        c.Response().Status,
-			Request:       reqBodyString,
-			Response:      resBodyString,
-		})
-
-		zap.S().Infof("Body dump: %s", string(j))
+	dumpBody(zap.S(), c, reqBody, resBody)
+}
+
+// BodyDumpWithConfig returns a BodyDump handler with config, usable with Echo's BodyDump middleware.
+func BodyDumpWithConfig(config BodyDumpConfig) func(c echo.Context, reqBody, resBody []byte) {
+	return func(c echo.Context, reqBody, resBody []byte) {
+		log := zap.S()
+		if config.Logger != nil {
+			log = config.Logger.Sugar()
+		}
+		dumpBody(log, c, reqBody, resBody)
+	}
+}
+
+func dumpBody(log *zap.SugaredLogger, c echo.Context, reqBody, resBody []byte) {
+	if shouldDumpBody(c, "") {
+		j, _ := json.Marshal(newBodyDumpModel(c, reqBody, resBody))
+
+		log.Infof("Body dump: %s", string(j))
+	}
+}
+
+func shouldDumpBody(c echo.Context, env string) bool {
+	return !isProduction(env) && c.Path() != "/healthz" && !isSkipPath(c)
+}
+
+func newBodyDumpModel(c echo.Context, reqBody, resBody []byte) BodyDumpModel {
+	version, commit := buildInfo()
+	return BodyDumpModel{
+		Host:          c.Request().Host,
+		Path:          c.Path(),
+		Method:        c.Request().Method,
+		RemoteAddress: c.Request().RemoteAddr,
+		Header:        fmt.Sprintf("%v", c.Request().Header),
+		Status:        c.Response().Status,
+		Request:       sanitizeDumpBody(c.Request().Header.Get(echo.HeaderContentType), reqBody),
+		Response:      sanitizeDumpBody(responseHeader(c.Response()).Get(echo.HeaderContentType), resBody),
+		Version:       version,
+		Commit:        commit,
 	}
 }
+
+// xmlInterTagSpace matches whitespace-only text between XML tags that spans a line break, i.e. indentation.
+// Runs on a single line, such as the space in <b>bold</b> <i>it</i> or <pad> </pad>, are not matched.
+var xmlInterTagSpace = regexp.MustCompile(`>[ \t\r]*\n\s*<`)
+
+func sanitizeDumpBody(contentType string, body []byte) string {
+	// Only indentation between tags is dropped so mixed content and whitespace-only values are kept
+	if isXMLContentType(contentType) {
+		return strings.TrimSpace(xmlInterTagSpace.ReplaceAllString(string(body), "><"))
+	}
+
+	bodyString := string(body)
+	bodyString = strings.ReplaceAll(bodyString, "\n", "")
+	bodyString = strings.ReplaceAll(bodyString, "\r", "")
+	bodyString = strings.ReplaceAll(bodyString, "\t", "")
+	return bodyString
+}
@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -58,6 +59,37 @@ func TestBodyDumpLogsSanitizedPayload(t *testing.T) {
 	assert.Contains(t, model.Header, "Content-Type")
 }
 
+func TestBodyDumpWithConfigUsesInjectedLogger(t *testing.T) {
+	viper.Set("ENVIRONMENT", "development")
+	t.Cleanup(func() {
+		viper.Set("ENVIRONMENT", "")
+	})
+
+	globalCore, globalObs := observer.New(zapcore.InfoLevel)
+	undo := zap.ReplaceGlobals(zap.New(globalCore))
+	t.Cleanup(func() { undo() })
+
+	core, obs := observer.New(zapcore.InfoLevel)
+
+	e := echo.New()
+	e.Use(middleware.BodyDump(BodyDumpWithConfig(BodyDumpConfig{Logger: zap.New(core)})))
+	e.POST("/api", func(c echo.Context) error {
+		return c.String(http.StatusOK, "pong")
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/api", strings.NewReader("ping"))
+	e.ServeHTTP(httptest.NewRecorder(), req)
+
+	entries := obs.All()
+	require.Len(t, entries, 1)
+	payload := strings.TrimPrefix(entries[0].Message, "Body dump: ")
+	var model BodyDumpModel
+	require.NoError(t, json.Unmarshal([]byte(payload), &model))
+	assert.Equal(t, "ping", model.Request)
+	assert.Equal(t, "pong", model.Response)
+	assert.Empty(t, globalObs.All())
+}
+
 func TestBodyDumpSkipsLoggingInProductionAndHealthz(t *testing.T) {
 	cases := []struct {
 		name string
@@ -89,3 +121,91 @@ func TestBodyDumpSkipsLoggingInProductionAndHealthz(t *testing.T) {
 		})
 	}
 }
+
+func TestZapLoggerIncludeBodyDump(t *testing.T) {
+	viper.Set("ENVIRONMENT", "development")
+	t.Cleanup(func() {
+		viper.Set("ENVIRONMENT", "")
+	})
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/api", strings.NewReader("{\n\t\"foo\":\"bar\"\n}"))
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetPath("/api")
+
+	core, obs := observer.New(zapcore.InfoLevel)
+	middleware := ZapLoggerWithConfig(ZapLoggerConfig{Logger: zap.New(core), IncludeBodyDump: true})
+	handler := middleware(func(c echo.Context) error {
+		return c.String(http.StatusOK, "first\nsecond")
+	})
+
+	require.NoError(t, handler(c))
+
+	entries := obs.All()
+	require.Len(t, entries, 1)
+	model, ok := entries[0].ContextMap()["body_dump"].(BodyDumpModel)
+	require.True(t, ok)
+	assert.Equal(t, "/api", model.Path)
+	assert.Equal(t, http.StatusOK, model.Status)
+	assert.Equal(t, "{\"foo\":\"bar\"}", model.Request)
+	assert.Equal(t, "firstsecond", model.Response)
+}
+
+func TestZapLoggerIncludeBodyDumpSkippedInProduction(t *testing.T) {
+	viper.Set("ENVIRONMENT", "production")
+	t.Cleanup(func() {
+		viper.Set("ENVIRONMENT", "")
+	})
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/api", strings.NewReader("req"))
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetPath("/api")
+
+	core, obs := observer.New(zapcore.InfoLevel)
+	middleware := ZapLoggerWithConfig(ZapLoggerConfig{Logger: zap.New(core), IncludeBodyDump: true})
+	handler := middleware(func(c echo.Context) error {
+		return c.String(http.StatusOK, "res")
+	})
+
+	require.NoError(t, handler(c))
+	require.Len(t, obs.All(), 1)
+	assert.NotContains(t, obs.All()[0].ContextMap(), "body_dump")
+}
+
+func TestBodyDumpCompactsXMLSafely(t *testing.T) {
+	viper.Set("ENVIRONMENT", "development")
+	t.Cleanup(func() {
+		viper.Set("ENVIRONMENT", "")
+	})
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/soap", strings.NewReader("ignored"))
+	req.Header.Set(echo.HeaderContentType, "text/xml; charset=utf-8")
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetPath("/soap")
+	c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationXMLCharsetUTF8)
+
+	core, obs := observer.New(zapcore.InfoLevel)
+	undo := zap.ReplaceGlobals(zap.New(core))
+	t.Cleanup(func() { undo() })
+
+	reqBody := "<Envelope>\n\t<Body>\n\t\t<Name>Jane\tDoe</Name>\n\t</Body>\n</Envelope>\n"
+	resBody := "<Result>\r\n  <Text>line one\nline two</Text>\r\n</Result>"
+	BodyDump(c, []byte(reqBody), []byte(resBody))
+
+	entries := obs.All()
+	require.Len(t, entries, 1)
+
+	var model BodyDumpModel
+	require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(entries[0].Message, "Body dump: ")), &model))
+	assert.Equal(t, "<Envelope><Body><Name>Jane\tDoe</Name></Body></Envelope>", model.Request)
+	assert.Equal(t, "<Result><Text>line one\nline two</Text></Result>", model.Response)
+
+	assert.Equal(t, "<p>a <b>bold</b> <i>it</i></p>", sanitizeDumpBody(echo.MIMEApplicationXML, []byte("<p>a <b>bold</b> <i>it</i></p>")))
+	assert.Equal(t, "<pad> </pad>", sanitizeDumpBody(echo.MIMEApplicationXML, []byte("<pad> </pad>")))
+	assert.Equal(t, "<r><pad>\t</pad></r>", sanitizeDumpBody(echo.MIMEApplicationXML, []byte("<r>\n  <pad>\t</pad>\n</r>\n")))
+}
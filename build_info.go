@@ -0,0 +1,42 @@
+package echomiddleware
+
+import (
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+var (
+	buildInfoMu  sync.RWMutex
+	buildVersion string
+	buildCommit  string
+)
+
+// SetBuildInfo tags every ZapLogger entry with build_version and build_commit and every body dump
+// with version and commit, e.g. SetBuildInfo("1.4.2", "9f1c2ab"). Empty values are omitted.
+func SetBuildInfo(version, commit string) {
+	buildInfoMu.Lock()
+	defer buildInfoMu.Unlock()
+	buildVersion = version
+	buildCommit = commit
+}
+
+func buildInfo() (version, commit string) {
+	buildInfoMu.RLock()
+	defer buildInfoMu.RUnlock()
+	return buildVersion, buildCommit
+}
+
+// buildInfoFields returns the build fields set with SetBuildInfo
+func buildInfoFields() []zapcore.Field {
+	version, commit := buildInfo()
+	var fields []zapcore.Field
+	if version != "" {
+		fields = append(fields, zap.String("build_version", version))
+	}
+	if commit != "" {
+		fields = append(fields, zap.String("build_commit", commit))
+	}
+	return fields
+}
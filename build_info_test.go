@@ -0,0 +1,49 @@
+package echomiddleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestSetBuildInfoTagsEntries(t *testing.T) {
+	viper.Set("ENVIRONMENT", "development")
+	t.Cleanup(func() {
+		viper.Set("ENVIRONMENT", "")
+		SetBuildInfo("", "")
+	})
+
+	core, obs := observer.New(zapcore.InfoLevel)
+	middleware := ZapLogger(zap.New(core), nil)
+	handler := middleware(func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	_, c, _ := newTestContext(t, http.MethodGet, "/test/123", "")
+	require.NoError(t, handler(c))
+	assert.NotContains(t, obs.All()[0].ContextMap(), "build_version")
+
+	SetBuildInfo("1.4.2", "9f1c2ab")
+
+	_, c, _ = newTestContext(t, http.MethodGet, "/test/123", "")
+	require.NoError(t, handler(c))
+	fields := obs.All()[1].ContextMap()
+	assert.Equal(t, "1.4.2", fields["build_version"])
+	assert.Equal(t, "9f1c2ab", fields["build_commit"])
+
+	dumpCore, dumpObs := observer.New(zapcore.InfoLevel)
+	BodyDumpWithConfig(BodyDumpConfig{Logger: zap.New(dumpCore)})(c, nil, nil)
+	var model BodyDumpModel
+	require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(dumpObs.All()[0].Message, "Body dump: ")), &model))
+	assert.Equal(t, "1.4.2", model.Version)
+	assert.Equal(t, "9f1c2ab", model.Commit)
+}
@@ -0,0 +1,40 @@
+package echomiddleware
+
+import (
+	"mime"
+	"strings"
+)
+
+// mediaType returns the lowercased media type of a Content-Type header without parameters
+func mediaType(contentType string) string {
+	parsed, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	}
+	return parsed
+}
+
+func isJSONContentType(contentType string) bool {
+	media := mediaType(contentType)
+	return media == "application/json" || strings.HasSuffix(media, "+json")
+}
+
+func isXMLContentType(contentType string) bool {
+	media := mediaType(contentType)
+	return media == "application/xml" || media == "text/xml" || strings.HasSuffix(media, "+xml")
+}
+
+// matchesContentType reports whether contentType has one of the allowed media types.
+// An empty allowlist matches everything.
+func matchesContentType(allowed []string, contentType string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	media := mediaType(contentType)
+	for _, candidate := range allowed {
+		if strings.EqualFold(candidate, media) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+package echomiddleware
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// defaultDedupCacheSize bounds the number of signatures tracked by the deduplicator
+const defaultDedupCacheSize = 128
+
+// logDeduper suppresses identical entries within a time window.
+// Signatures are kept in a small LRU so a storm of distinct errors cannot grow it unbounded.
+type logDeduper struct {
+	mu      sync.Mutex
+	window  time.Duration
+	size    int
+	order   *list.List
+	entries map[string]*list.Element
+	now     func() time.Time
+}
+
+type dedupEntry struct {
+	signature  string
+	since      time.Time
+	suppressed int
+}
+
+func newLogDeduper(window time.Duration, size int) *logDeduper {
+	if size < 1 {
+		size = defaultDedupCacheSize
+	}
+	return &logDeduper{
+		window:  window,
+		size:    size,
+		order:   list.New(),
+		entries: make(map[string]*list.Element, size),
+		now:     time.Now,
+	}
+}
+
+// observe reports whether an entry with the signature should be logged and, if so, how many
+// identical entries were suppressed since the previous one was logged
+func (d *logDeduper) observe(signature string) (bool, int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+	if element, ok := d.entries[signature]; ok {
+		entry := element.Value.(*dedupEntry)
+		d.order.MoveToFront(element)
+		if now.Sub(entry.since) < d.window {
+			entry.suppressed++
+			return false, 0
+		}
+		suppressed := entry.suppressed
+		entry.since = now
+		entry.suppressed = 0
+		return true, suppressed
+	}
+
+	d.entries[signature] = d.order.PushFront(&dedupEntry{signature: signature, since: now})
+	if d.order.Len() > d.size {
+		oldest := d.order.Back()
+		d.order.Remove(oldest)
+		delete(d.entries, oldest.Value.(*dedupEntry).signature)
+	}
+	return true, 0
+}
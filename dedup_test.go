@@ -0,0 +1,74 @@
+package echomiddleware
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLogDeduperWindow(t *testing.T) {
+	now := time.Unix(0, 0)
+	deduper := newLogDeduper(time.Minute, 0)
+	deduper.now = func() time.Time { return now }
+
+	ok, suppressed := deduper.observe("500 /orders boom")
+	assert.True(t, ok)
+	assert.Zero(t, suppressed)
+
+	for i := 0; i < 3; i++ {
+		now = now.Add(time.Second)
+		ok, _ = deduper.observe("500 /orders boom")
+		assert.False(t, ok)
+	}
+
+	ok, _ = deduper.observe("500 /payments boom")
+	assert.True(t, ok)
+
+	now = now.Add(time.Minute)
+	ok, suppressed = deduper.observe("500 /orders boom")
+	assert.True(t, ok)
+	assert.Equal(t, 3, suppressed)
+}
+
+func TestLogDeduperEvictsLeastRecentlyUsed(t *testing.T) {
+	deduper := newLogDeduper(time.Hour, 2)
+
+	deduper.observe("a")
+	deduper.observe("b")
+	deduper.observe("a")
+	deduper.observe("c")
+
+	assert.Len(t, deduper.entries, 2)
+	assert.Contains(t, deduper.entries, "a")
+	assert.NotContains(t, deduper.entries, "b")
+}
+
+func TestZapLoggerDeduplicatesRepeatedErrors(t *testing.T) {
+	core, obs := observer.New(zapcore.InfoLevel)
+	middleware := ZapLoggerWithConfig(ZapLoggerConfig{Logger: zap.New(core), DedupWindow: time.Hour})
+	handler := middleware(func(c echo.Context) error {
+		return errors.New("dependency down")
+	})
+
+	for i := 0; i < 5; i++ {
+		_, c, _ := newTestContext(t, http.MethodGet, "/test/123", "")
+		require.NoError(t, handler(c))
+	}
+
+	_, c, _ := newTestContext(t, http.MethodGet, "/test/123", "")
+	successHandler := middleware(func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+	require.NoError(t, successHandler(c))
+
+	assert.Equal(t, 1, obs.FilterMessage("Server error").Len())
+	assert.Equal(t, 1, obs.FilterMessage("Success").Len())
+}
@@ -1,18 +1,35 @@
 package echomiddleware
 
 import (
+	"bufio"
 	"bytes"
+	"compress/gzip"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"math"
+	"math/rand"
+	"net"
 	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
+	"unicode/utf8"
 
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	otellog "go.opentelemetry.io/otel/log"
 	"go.opentelemetry.io/otel/trace"
 
 	"github.com/gorilla/websocket"
 	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/random"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
@@ -25,130 +42,1259 @@ type responseWriter struct {
 func (w *responseWriter) Write(b []byte) (int, error) {
 	return w.Writer.Write(b)
 }
+
+// Flush delegates to the underlying writer when it supports flushing
+func (w *responseWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack delegates to the underlying writer. Bytes written to a hijacked connection are not captured.
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if hijacker, ok := w.ResponseWriter.(http.Hijacker); ok {
+		return hijacker.Hijack()
+	}
+	return nil, nil, http.ErrNotSupported
+}
+
+// Push delegates to the underlying writer when it supports HTTP/2 server push
+func (w *responseWriter) Push(target string, opts *http.PushOptions) error {
+	if pusher, ok := w.ResponseWriter.(http.Pusher); ok {
+		return pusher.Push(target, opts)
+	}
+	return http.ErrNotSupported
+}
+
+// Unwrap returns the underlying writer for http.ResponseController
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// ZapLoggerConfig defines the config for ZapLogger middleware.
+type ZapLoggerConfig struct {
+	// Logger receives the access log entries. Defaults to zap.L().
+	Logger *zap.Logger
+
+	// JSONOutput, when set, writes access log entries as JSON to the syncer through a dedicated core,
+	// regardless of the encoder configured on Logger, e.g. a console encoder for startup logs.
+	// The entries still follow the level enabled on Logger.
+	JSONOutput zapcore.WriteSyncer
+
+	// Collection, when set, persists every access log entry to MongoDB. A collection not obtained from
+	// a client, such as &mongo.Collection{}, is ignored with a single warning when the middleware is created.
+	Collection *mongo.Collection
+
+	// Sink, when set, receives every access log entry as a document.
+	// It takes precedence over CollectionResolver and Collection.
+	Sink LogSink
+
+	// CollectionResolver, when set, picks the collection each entry is persisted to once the handler has
+	// completed, e.g. a per-tenant database. Entries for which it returns nil, or a collection not
+	// obtained from a client, are not persisted; the latter is warned about once.
+	// It takes precedence over Collection.
+	CollectionResolver func(c echo.Context) *mongo.Collection
+
+	// CaptureResponseOn reports whether the response body is logged for a status code.
+	// The body is buffered while the handler runs and discarded afterwards when it returns false,
+	// since the status is only known once the handler completes. Nil logs every response body.
+	CaptureResponseOn func(status int) bool
+
+	// CaptureBodyOn reports whether the request body is logged for a status code, e.g. StatusClasses(4, 5)
+	// to keep bodies of failed requests only. The body must be read before the handler runs, so it is
+	// still buffered in full for every request and discarded afterwards; this limits retention, not memory.
+	// Nil logs every request body.
+	CaptureBodyOn func(status int) bool
+
+	// PIIMasker, when set, masks PII in the logged request and response bodies. Nil disables masking.
+	PIIMasker *PIIMasker
+
+	// DecodeGzipResponse decompresses the captured response body before logging it when the response
+	// carries Content-Encoding: gzip. ZapLogger must be registered before Echo's Gzip middleware so the
+	// compressor wraps the capturing writer; otherwise the body is already plain and is logged as is.
+	// Decoding stops at 1 MiB, in which case the decoded prefix is logged with response_truncated: true.
+	DecodeGzipResponse bool
+
+	// DecodeGzipRequest decompresses request bodies sent with Content-Encoding: gzip before logging them
+	// and logs body_compressed_bytes and body_decompressed_bytes. The handler still receives the
+	// compressed body. Bodies truncated by MaxBodyLogBytes cannot be decoded and are logged as is.
+	// Decoding stops at MaxBodyLogBytes, or 1 MiB when unset, so a small body cannot inflate without
+	// bound; the decoded prefix is then logged with body_truncated: true and no body_decompressed_bytes.
+	DecodeGzipRequest bool
+
+	// BinaryBodyPlaceholder is logged instead of request and response bodies that are not valid UTF-8.
+	// A %d verb is replaced with the body size. Defaults to "<binary %d bytes>".
+	BinaryBodyPlaceholder string
+
+	// IncludeHostMetadata adds the hostname and pid of the serving process to every entry.
+	// Both values are resolved once when the middleware is created.
+	IncludeHostMetadata bool
+
+	// IncludeBodyDump folds the BodyDump payload into the access log entry as a nested body_dump
+	// object, producing one record per request instead of a separate body dump entry.
+	// It follows the same environment and /healthz rules as BodyDump.
+	IncludeBodyDump bool
+
+	// RequestHeaderFields lists request headers promoted to top-level fields named
+	// req.<lowercased header>, e.g. X-Tenant-ID is logged as req.x-tenant-id. Absent headers are omitted.
+	RequestHeaderFields []string
+
+	// OmitHeader leaves the full request header out of the entry. The header is otherwise rendered lazily,
+	// only when a core actually encodes the entry.
+	OmitHeader bool
+
+	// MaxHeaderValues caps the values logged per key in the header field; extra values are summarized
+	// as (+N more). Zero logs every value.
+	MaxHeaderValues int
+
+	// MaxHeaderValueBytes truncates each value logged in the header field on a UTF-8 rune boundary,
+	// appending (+N more) with the number of dropped bytes. Zero logs values in full.
+	MaxHeaderValueBytes int
+
+	// RequestHeaderFieldMap maps request headers to the field names they are logged under, e.g.
+	// {"Idempotency-Key": "idempotency_key", "X-Retry-Count": "retry_count"}. Absent headers are omitted.
+	RequestHeaderFieldMap map[string]string
+
+	// RouteGroups lists route group prefixes, e.g. /api/v1, logged as route_group for routes registered
+	// under them. Echo keeps no group reference on routes, so the longest prefix matching the route path
+	// on a segment boundary is logged. Routes outside every group omit the field.
+	RouteGroups []string
+
+	// LatencyFormat selects how latency is logged: a duration string (the default),
+	// float milliseconds, or integer microseconds.
+	LatencyFormat LatencyFormat
+
+	// Environment overrides the ENVIRONMENT value read through viper for environment-aware behavior.
+	Environment string
+
+	// Redactor, when set, rewrites the request and response bodies before they are logged.
+	Redactor Redactor
+
+	// MaxBodyLogBytes caps the request body read for logging. Longer bodies, including chunked ones of
+	// unknown length, are logged up to the limit with body_truncated: true, while the handler still reads
+	// the full stream. The fingerprint and multipart fields then cover the logged prefix only.
+	// Zero reads the whole body.
+	MaxBodyLogBytes int
+
+	// MaxEntryBytes caps the JSON-encoded size of an entry's fields. Oversized entries drop the
+	// body_dump, header, response, and body fields in that order until they fit and are flagged truncated: true.
+	// Zero disables the limit.
+	MaxEntryBytes int
+
+	// ErrorCodeFunc, when set, extracts a domain error code from the error returned by the handler.
+	// The code is logged as error_domain_code when ok is true.
+	ErrorCodeFunc func(err error) (code string, ok bool)
+
+	// SkipMethods lists request methods, e.g. OPTIONS for CORS preflights, that are neither logged nor persisted.
+	SkipMethods []string
+
+	// MinLevel drops entries leveled below it from both zap and persistence, e.g. zapcore.WarnLevel keeps
+	// 4xx and 5xx entries only. Defaults to zapcore.InfoLevel, which keeps every entry.
+	MinLevel zapcore.Level
+
+	// MessageField, when set, also logs the status-class message (Success, Redirection, Client error, or
+	// Server error) under this key, e.g. event, for pipelines that do not read zap's msg. Empty adds none.
+	MessageField string
+
+	// PersistOn reports whether an entry with the status code is sent to the Sink or Collection.
+	// It does not affect zap logging. Nil persists every entry.
+	PersistOn func(status int) bool
+
+	// PersistSampledOnly sends an entry to the Sink or Collection only when the request span was sampled,
+	// keeping persisted logs aligned with the stored traces. Requests without a span are not persisted.
+	// It does not affect zap logging.
+	PersistSampledOnly bool
+
+	// SlowRequestThreshold, when positive, emits an interim "Request still running" warning with the
+	// elapsed time and correlation IDs for requests that have not completed after the duration.
+	SlowRequestThreshold time.Duration
+
+	// EnsureResponseRequestID writes the observed request ID to the response header before the handler
+	// runs, generating one with RequestIDGenerator when neither the request nor the response carries it.
+	EnsureResponseRequestID bool
+
+	// RequestIDGenerator generates missing request IDs. Defaults to a random 32 character string.
+	RequestIDGenerator func() string
+
+	// PreferRequestHeaderRequestID logs the client-supplied request ID header ahead of the response header.
+	// By default the response header wins, matching Echo's RequestID middleware, which sets the canonical ID there.
+	PreferRequestHeaderRequestID bool
+
+	// LogBodyContentTypes restricts request body logging to these media types, e.g. application/json.
+	// Bodies of other types are neither buffered nor logged. Empty logs every body.
+	LogBodyContentTypes []string
+
+	// MetadataOnlyContentTypes lists request media types, e.g. multipart/form-data, whose bodies are neither
+	// buffered nor logged. Their entries carry content_length, content_type, and the uploaded filenames
+	// instead: filename from the Content-Disposition header, filenames from a form parsed by the handler.
+	MetadataOnlyContentTypes []string
+
+	// BodyChunkHashRoutes lists upload routes, as returned by c.Path(), whose request bodies are logged as
+	// body_chunk_hashes, the hex SHA-256 of each BodyChunkHashSize chunk, instead of body. Chunks are
+	// hashed while the handler reads the body, so it is never buffered and unread bytes are not hashed.
+	BodyChunkHashRoutes []string
+
+	// BodyChunkHashSize is the chunk size for BodyChunkHashRoutes. Defaults to 1 MiB.
+	BodyChunkHashSize int
+
+	// LogMultipartFields logs the value field names of multipart/form-data requests as form_fields and their
+	// files as uploaded_files with sizes, in place of the body. The buffered body is parsed as a copy, so
+	// the handler reads the request as usual. It applies only to request bodies ZapLogger reads.
+	LogMultipartFields bool
+
+	// MultipartMaxMemory bounds the memory used to parse multipart forms for LogMultipartFields.
+	// Larger parts spill to temporary files removed after parsing. Defaults to 32 MB.
+	MultipartMaxMemory int64
+
+	// DebugBodyHeader names a request header, e.g. X-Debug-Body, that opts a single request into body
+	// logging when set to a true value such as 1. It overrides BodySampleRates, LogBodyContentTypes,
+	// MetadataOnlyContentTypes, CaptureBodyOn, and CaptureResponseOn; redaction and masking still apply.
+	// The header is ignored in production unless AllowDebugBodyInProduction is set.
+	DebugBodyHeader string
+
+	// AllowDebugBodyInProduction honors DebugBodyHeader in production.
+	AllowDebugBodyInProduction bool
+
+	// SessionCookieName, when set, logs has_session for requests carrying the cookie and
+	// cookie_count for every request. Cookie values are never logged.
+	SessionCookieName string
+
+	// OTelLogger, when set, also emits every entry as an OpenTelemetry log record with the request
+	// context, so an OTel logs SDK correlates it with the active trace and span. To emit OTLP records
+	// only, set Logger to zap.NewNop().
+	OTelLogger otellog.Logger
+
+	// DateField names the BSON date field added to persisted documents, usable by date operators and
+	// TTL indexes (see TTLIndexModel). Defaults to "created_at".
+	DateField string
+
+	// LogJWTSubject logs the sub claim of the bearer token as jwt_sub outside production.
+	// The token is decoded without signature verification and malformed tokens are ignored.
+	LogJWTSubject bool
+
+	// LogHandlerName logs the function name of the handler selected by the router as handler outside
+	// production, e.g. github.com/acme/api.getOrder. Resolving it scans the registered routes on every request.
+	LogHandlerName bool
+
+	// LogContextKeys lists Echo context keys, stored with c.Set, whose values are logged outside production
+	// as ctx_<key> fields, formatted with fmt.Sprint. Keys holding no value are skipped.
+	LogContextKeys []string
+
+	// SyncInsert persists entries inline before the middleware returns instead of in a goroutine.
+	SyncInsert bool
+
+	// FailOnInsertError returns the insert error from the middleware when SyncInsert is set.
+	// The response has already been written by then, so the error reaches outer middleware only.
+	FailOnInsertError bool
+
+	// MaxConcurrentInserts, when positive, caps the insert goroutines in flight. Entries arriving while the
+	// cap is reached are dropped rather than queued and counted by DroppedInserts. It is ignored with SyncInsert.
+	MaxConcurrentInserts int
+
+	// RedactQueryParams lists query parameters whose values are masked in the uri, query, form, and
+	// full_url fields, including those of the slow-request entry.
+	RedactQueryParams []string
+
+	// URIWithoutQuery logs uri without its query string, which remains available in the query field.
+	URIWithoutQuery bool
+
+	// CountryHeader names a request header carrying the client country set by a CDN, e.g. CF-IPCountry,
+	// logged as country. The field is empty when the header is absent.
+	CountryHeader string
+
+	// LogAcceptLanguage logs the Accept-Language header as accept_language when present.
+	LogAcceptLanguage bool
+
+	// ParseAcceptLanguage logs only the preferred language tag of the header for LogAcceptLanguage.
+	ParseAcceptLanguage bool
+
+	// LogHTTPVersion logs http_version, the protocol major version (1, 2, or 3) next to request_proto.
+	LogHTTPVersion bool
+
+	// LogFingerprint logs fingerprint, a SHA-256 of the method, path, sorted query, and request body hash,
+	// grouping identical requests. Bodies that are not read, see LogBodyContentTypes, hash as empty.
+	LogFingerprint bool
+
+	// LogFullURL logs full_url, the request path followed by the query with RedactQueryParams masked.
+	LogFullURL bool
+
+	// DedupWindow, when positive, suppresses 4xx and 5xx entries identical in status, route, and handler
+	// error logged within the window. The next entry logged after the window carries suppressed_count.
+	DedupWindow time.Duration
+
+	// DedupCacheSize bounds the number of distinct signatures tracked for DedupWindow. Defaults to 128.
+	DedupCacheSize int
+
+	// ParseServerTiming logs each Server-Timing response metric with a duration as <name>_ms,
+	// e.g. upstream;dur=42 as upstream_ms. Malformed metrics are ignored.
+	ParseServerTiming bool
+
+	// ResponseCapture, when set, replaces the response buffer. It returns the writer that receives a copy
+	// of the response and a finalizer yielding the logged response string, e.g. a hash for streaming
+	// responses. DecodeGzipResponse does not apply to custom captures. Nil buffers the whole response.
+	ResponseCapture func(c echo.Context) (io.Writer, func() string)
+
+	// BodySampleRates maps route paths, as returned by c.Path(), to the fraction of requests whose request
+	// and response bodies are captured and logged, e.g. {"/orders/:id": 0.01}. Unsampled requests on those
+	// routes are logged without bodies, which are then neither buffered nor read. Other routes are unaffected.
+	BodySampleRates map[string]float64
+
+	// ZapFieldsFunc, when set, transforms the fields of each entry before it is logged through Logger and
+	// OTelLogger, e.g. to drop the header. It receives a copy, so persisted documents are unaffected.
+	ZapFieldsFunc func(fields []zapcore.Field) []zapcore.Field
+
+	// MongoDocFunc, when set, transforms each document before it is persisted to the Sink or Collection.
+	// Zap logging is unaffected.
+	MongoDocFunc func(document map[string]interface{}) map[string]interface{}
+
+	// OnLog, when set, is called synchronously with every logged entry, e.g. to stream entries to a live
+	// debugging UI. The entry holds the logged fields plus level and message and is not shared with sinks.
+	// The callback runs on the request path, so slow consumers should hand entries off to a buffered channel.
+	OnLog func(entry map[string]interface{})
+
+	// PropagateErrors returns handler errors from the middleware instead of passing them to c.Error,
+	// leaving the response to an outer error middleware. The entry is leveled by the HTTPError code,
+	// or 500 for other errors, unless the response was already committed.
+	PropagateErrors bool
+}
+
+// LatencyFormat selects the representation of the latency field
+type LatencyFormat int
+
+const (
+	// LatencyString logs latency as a duration string, e.g. "1.2ms"
+	LatencyString LatencyFormat = iota
+	// LatencyMilliseconds logs latency as float milliseconds, e.g. 1.2
+	LatencyMilliseconds
+	// LatencyMicroseconds logs latency as integer microseconds, e.g. 1200
+	LatencyMicroseconds
+)
+
+// latencyField returns the latency field in the configured format
+func latencyField(latency time.Duration, format LatencyFormat) zapcore.Field {
+	switch format {
+	case LatencyMilliseconds:
+		return zap.Float64("latency", float64(latency)/float64(time.Millisecond))
+	case LatencyMicroseconds:
+		return zap.Int64("latency", latency.Microseconds())
+	default:
+		return zap.String("latency", latency.String())
+	}
+}
+
+// defaultDateField names the BSON date field of persisted documents
+const defaultDateField = "created_at"
+
+// defaultBinaryBodyPlaceholder is logged for bodies that are not valid UTF-8
+const defaultBinaryBodyPlaceholder = "<binary %d bytes>"
+
+// notCapturedPlaceholder is logged as response when the handler wrote a body that bypassed the capture
+// writer, e.g. through a writer reference taken before ZapLogger wrapped it.
+const notCapturedPlaceholder = "<not captured>"
+
+// StatusClasses returns a predicate matching status codes in the given classes,
+// e.g. StatusClasses(4, 5) matches every 4xx and 5xx status.
+func StatusClasses(classes ...int) func(status int) bool {
+	return func(status int) bool {
+		for _, class := range classes {
+			if status/100 == class {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// ZapLogger returns a middleware that logs every request to log and, when collection is not nil,
+// persists the entry to MongoDB.
 func ZapLogger(log *zap.Logger, collection *mongo.Collection) echo.MiddlewareFunc {
+	return ZapLoggerWithConfig(ZapLoggerConfig{Logger: log, Collection: collection})
+}
+
+// ZapLoggerForEnvironment returns a ZapLogger middleware that persists entries to the configured
+// Sink, Collection, or CollectionResolver only in production. Other environments log through zap alone.
+func ZapLoggerForEnvironment(config ZapLoggerConfig) echo.MiddlewareFunc {
+	if !isProduction(config.Environment) {
+		config.Sink = nil
+		config.Collection = nil
+		config.CollectionResolver = nil
+	}
+	return ZapLoggerWithConfig(config)
+}
+
+// ZapLoggerWithConfig returns a ZapLogger middleware with config.
+func ZapLoggerWithConfig(config ZapLoggerConfig) echo.MiddlewareFunc {
+	log := config.Logger
+	if log == nil {
+		log = zap.L()
+	}
+	if config.JSONOutput != nil {
+		encoder := zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig())
+		log = zap.New(zapcore.NewCore(encoder, config.JSONOutput, zap.LevelEnablerFunc(log.Core().Enabled)))
+	}
+
+	if config.BinaryBodyPlaceholder == "" {
+		config.BinaryBodyPlaceholder = defaultBinaryBodyPlaceholder
+	}
+
+	if config.DateField == "" {
+		config.DateField = defaultDateField
+	}
+	if config.BodyChunkHashSize <= 0 {
+		config.BodyChunkHashSize = defaultBodyChunkHashSize
+	}
+	if config.MultipartMaxMemory <= 0 {
+		config.MultipartMaxMemory = defaultMultipartMaxMemory
+	}
+	if config.RequestIDGenerator == nil {
+		config.RequestIDGenerator = generateRequestID
+	}
+
+	var hostFields []zapcore.Field
+	if config.IncludeHostMetadata {
+		hostname, _ := os.Hostname()
+		hostFields = []zapcore.Field{
+			zap.String("hostname", hostname),
+			zap.Int("pid", os.Getpid()),
+		}
+	}
+
+	var deduper *logDeduper
+	if config.DedupWindow > 0 {
+		deduper = newLogDeduper(config.DedupWindow, config.DedupCacheSize)
+	}
+
+	var insertSlots chan struct{}
+	if config.MaxConcurrentInserts > 0 {
+		insertSlots = make(chan struct{}, config.MaxConcurrentInserts)
+	}
+
+	sink := config.Sink
+	if sink == nil && config.CollectionResolver == nil && config.Collection != nil {
+		if collectionInitialized(config.Collection) {
+			sink = mongoSink{collection: config.Collection}
+		} else {
+			log.Warn("Mongo collection is not initialized, access logs will not be persisted")
+		}
+	}
+	var resolvedCollectionWarning sync.Once
+
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
 
-			if websocket.IsWebSocketUpgrade(c.Request()) {
+			if websocket.IsWebSocketUpgrade(c.Request()) || isSkipPath(c) || skipMethod(config.SkipMethods, c.Request().Method) {
 				return next(c)
 			}
 
 			start := time.Now()
 
+			ctx, state := withLogState(c.Request().Context())
+			c.SetRequest(c.Request().WithContext(ctx))
 			req := c.Request()
 
 			var (
-				bodyBytes []byte
-				err       error
+				bodyBytes      []byte
+				bodyTruncated  bool
+				lengthMismatch bool
+				err            error
 			)
-			if !websocket.IsWebSocketUpgrade(req) {
-				bodyBytes, err = readAndResetBody(req)
-				if err != nil {
+			forceBodies := debugBodyRequested(req, &config)
+			sampleBodies := true
+			if rate, ok := config.BodySampleRates[c.Path()]; ok && !forceBodies {
+				sampleBodies = bodySampler() < rate
+			}
+			metadataOnly := !forceBodies && len(config.MetadataOnlyContentTypes) > 0 &&
+				matchesContentType(config.MetadataOnlyContentTypes, req.Header.Get(echo.HeaderContentType))
+			var chunkHasher *chunkHashReader
+			for _, route := range config.BodyChunkHashRoutes {
+				if route == c.Path() && req.Body != nil {
+					chunkHasher = newChunkHashReader(req.Body, config.BodyChunkHashSize)
+					req.Body = chunkHasher
+					break
+				}
+			}
+			// The body must be read before next for it to be logged, whatever the status turns out to be.
+			// Requests that declare no body, such as most GET requests, are passed through without a read.
+			readBody := chunkHasher == nil && sampleBodies && !metadataOnly && !websocket.IsWebSocketUpgrade(req) && requestHasBody(req) &&
+				(forceBodies || matchesContentType(config.LogBodyContentTypes, req.Header.Get(echo.HeaderContentType)))
+			var multipartFields, compressionFields []zapcore.Field
+			if readBody {
+				bodyBytes, bodyTruncated, err = readAndResetBody(req, config.MaxBodyLogBytes)
+				if errors.Is(err, io.ErrUnexpectedEOF) {
+					// The client sent fewer bytes than it declared; log what arrived and let the handler see the error
+					lengthMismatch, err = true, nil
+				} else if err != nil {
 					return err
+				} else {
+					lengthMismatch = contentLengthMismatch(req.ContentLength, len(bodyBytes), bodyTruncated, config.MaxBodyLogBytes)
+				}
+			}
+			var fingerprint string
+			if config.LogFingerprint {
+				fingerprint = requestFingerprint(req, bodyBytes)
+			}
+			if readBody {
+				if config.DecodeGzipRequest && !bodyTruncated && strings.EqualFold(req.Header.Get(echo.HeaderContentEncoding), "gzip") {
+					if decoded, truncated, err := decodeGzip(bodyBytes, decodedBodyLimit(config.MaxBodyLogBytes)); err == nil {
+						compressionFields = []zapcore.Field{zap.Int("body_compressed_bytes", len(bodyBytes))}
+						if !truncated {
+							compressionFields = append(compressionFields, zap.Int("body_decompressed_bytes", len(decoded)))
+						}
+						bodyBytes, bodyTruncated = decoded, truncated
+					}
+				}
+				if contentType := req.Header.Get(echo.HeaderContentType); config.LogMultipartFields && mediaType(contentType) == echo.MIMEMultipartForm {
+					multipartFields = multipartFormFields(bodyBytes, contentType, config.MultipartMaxMemory)
+					bodyBytes = nil
 				}
 			}
 
 			resBody := new(bytes.Buffer)
-			mw := io.MultiWriter(c.Response().Writer, resBody)
-			writer := &responseWriter{Writer: mw, ResponseWriter: c.Response().Writer}
+			captured := resBody.String
+			capturing := false
+			// HEAD responses carry no body, so their writer is left unwrapped and response is logged empty.
+			// A nil writer, seen in some tests and proxies, is left as is rather than wrapped.
+			if c.Response().Writer != nil && req.Method != http.MethodHead && !websocket.IsWebSocketUpgrade(req) {
+				var captureWriter io.Writer = resBody
+				if !sampleBodies {
+					captureWriter = io.Discard
+				} else if config.ResponseCapture != nil {
+					captureWriter, captured = config.ResponseCapture(c)
+				}
+				mw := io.MultiWriter(c.Response().Writer, captureWriter)
+				c.Response().Writer = &responseWriter{Writer: mw, ResponseWriter: c.Response().Writer}
+				capturing = sampleBodies
+			}
 
-			if !websocket.IsWebSocketUpgrade(req) {
-				c.Response().Writer = writer
+			if config.EnsureResponseRequestID && c.Response().Writer != nil {
+				ensureResponseRequestID(c, config.RequestIDGenerator)
 			}
 
-			err = next(c)
+			if config.SlowRequestThreshold > 0 {
+				// Deferred so a handler panic recovered further out does not leave the timer armed
+				watchdog := startSlowRequestWatchdog(log, c, start, &config)
+				defer watchdog.Stop()
+			}
+			err = serveInFlight(next, c)
+			var handlerErr error
 			if err != nil {
-				c.Error(err)
+				if config.PropagateErrors {
+					handlerErr = err
+				} else {
+					c.Error(err)
+				}
 			}
 
 			res := c.Response()
 
-			requestID := req.Header.Get(echo.HeaderXRequestID)
-			if requestID == "" {
-				requestID = res.Header().Get(echo.HeaderXRequestID)
+			// Level by the HTTPError code when the error handler has not written the response yet
+			status := res.Status
+			var errorFields []zapcore.Field
+			var httpErr *echo.HTTPError
+			if errors.As(err, &httpErr) {
+				errorFields = append(errorFields,
+					zap.Int("error_code", httpErr.Code),
+					zap.String("error_message", fmt.Sprint(httpErr.Message)),
+				)
+				if !res.Committed {
+					status = httpErr.Code
+				}
+			} else if err != nil && !res.Committed {
+				status = http.StatusInternalServerError
+			}
+			errorFields = append(errorFields, validationErrorFields(err)...)
+			if err != nil && config.ErrorCodeFunc != nil {
+				if code, ok := config.ErrorCodeFunc(err); ok {
+					errorFields = append(errorFields, zap.String("error_domain_code", code))
+				}
 			}
 
-			span := GetSpanFromContext(c.Request().Context())
-			tracerID := GetTraceIDFromContext(c.Request().Context())
-			spanID := span.SpanContext().SpanID().String()
+			requestID := observedRequestID(req, res, config.PreferRequestHeaderRequestID)
 
-			params := fmt.Sprintf("%v", c.ParamValues())
+			tracerID, spanID := correlationIDs(c.Request().Context())
 
+			paramValues := c.ParamValues()
+			params := fmt.Sprintf("%v", paramValues)
+
+			response := captured()
+			responseTruncated := false
+			if capturing && response == "" && res.Size > 0 {
+				response = notCapturedPlaceholder
+			}
+			if config.ResponseCapture == nil && config.DecodeGzipResponse && strings.EqualFold(responseHeader(res).Get(echo.HeaderContentEncoding), "gzip") {
+				if decoded, truncated, err := decodeGzip(resBody.Bytes(), defaultMaxDecodedBodyBytes); err == nil {
+					response, responseTruncated = string(decoded), truncated
+				}
+			}
+			if config.CaptureResponseOn != nil && !config.CaptureResponseOn(status) && !forceBodies {
+				response = ""
+			}
+			if config.CaptureBodyOn != nil && !config.CaptureBodyOn(status) && !forceBodies {
+				bodyBytes = nil
+			}
+
+			if config.Redactor != nil {
+				bodyBytes = config.Redactor.Redact(req.Header.Get(echo.HeaderContentType), bodyBytes)
+				response = string(config.Redactor.Redact(responseHeader(res).Get(echo.HeaderContentType), []byte(response)))
+			}
+
+			body := loggableBody(string(bodyBytes), config.BinaryBodyPlaceholder)
+			response = loggableBody(response, config.BinaryBodyPlaceholder)
+			if config.PIIMasker != nil {
+				body = config.PIIMasker.Mask(body)
+				response = config.PIIMasker.Mask(response)
+			}
+
+			query := c.QueryString()
+			if len(config.RedactQueryParams) > 0 {
+				query = redactQuery(req.URL.Query(), config.RedactQueryParams)
+			}
+
+			uri := loggedURI(req, &config)
+			form := req.Form.Encode()
+			if len(config.RedactQueryParams) > 0 {
+				form = redactQuery(req.Form, config.RedactQueryParams)
+			}
+
+			var header fmt.Stringer = headerStringer(req.Header)
+			if config.MaxHeaderValues > 0 || config.MaxHeaderValueBytes > 0 {
+				header = cappedHeader{header: req.Header, maxValues: config.MaxHeaderValues, maxBytes: config.MaxHeaderValueBytes}
+			}
+			headerField := zap.Stringer("header", header)
+			if config.OmitHeader {
+				headerField = zap.Skip()
+			}
+
+			end := time.Now()
 			fields := []zapcore.Field{
-				zap.Int("status", res.Status),
-				zap.String("latency", time.Since(start).String()),
+				zap.Int("status", status),
+				zap.String("outcome", statusOutcome(status)),
+				latencyField(time.Since(start), config.LatencyFormat),
 				zap.String("request_id", requestID),
 				zap.String("trace_id", tracerID),
 				zap.String("span_id", spanID),
-				zap.String("time", time.Now().Format(time.RFC3339)),
-				zap.Int64("timestamp", time.Now().Unix()),
+				zap.String("time", end.Format(time.RFC3339)),
+				zap.Int64("timestamp", end.Unix()),
 				zap.String("method", req.Method),
-				zap.String("uri", req.RequestURI),
+				zap.String("uri", uri),
 				zap.String("host", req.Host),
 				zap.String("remote_ip", c.RealIP()),
-				zap.String("header", fmt.Sprintf("%v", req.Header)),
+				headerField,
 				zap.String("path", c.Path()),
-				zap.String("query", c.QueryString()),
-				zap.String("form", req.Form.Encode()),
+				zap.String("query", query),
+				zap.String("form", form),
 				zap.String("param", params),
-				zap.String("body", string(bodyBytes)),
+				zap.String("body", body),
 				zap.String("user_agent", req.UserAgent()),
 				zap.String("referer", req.Referer()),
 				zap.String("request_proto", req.Proto),
-				zap.String("response", resBody.String()),
+				zap.String("response", response),
+			}
+			if config.IncludeBodyDump && shouldDumpBody(c, config.Environment) {
+				fields = append(fields, zap.Any("body_dump", newBodyDumpModel(c, []byte(body), []byte(response))))
+			}
+			if config.LogFullURL {
+				fullURL := req.URL.Path
+				if query != "" {
+					fullURL += "?" + query
+				}
+				fields = append(fields, zap.String("full_url", fullURL))
+			}
+			fields = append(fields, paramFields(c.ParamNames(), paramValues)...)
+			if config.CountryHeader != "" {
+				fields = append(fields, zap.String("country", req.Header.Get(config.CountryHeader)))
+			}
+			if config.LogAcceptLanguage {
+				acceptLanguage := req.Header.Get("Accept-Language")
+				if config.ParseAcceptLanguage {
+					acceptLanguage = primaryLanguage(acceptLanguage)
+				}
+				if acceptLanguage != "" {
+					fields = append(fields, zap.String("accept_language", acceptLanguage))
+				}
+			}
+			if bodyTruncated {
+				fields = append(fields, zap.Bool("body_truncated", true))
+			}
+			if responseTruncated {
+				fields = append(fields, zap.Bool("response_truncated", true))
+			}
+			if lengthMismatch {
+				fields = append(fields, zap.Bool("content_length_mismatch", true))
+			}
+			if config.LogHTTPVersion {
+				fields = append(fields, zap.Int("http_version", req.ProtoMajor))
+			}
+			if config.LogFingerprint {
+				fields = append(fields, zap.String("fingerprint", fingerprint))
+			}
+			if group := routeGroup(c.Path(), config.RouteGroups); group != "" {
+				fields = append(fields, zap.String("route_group", group))
+			}
+			if metadataOnly {
+				fields = append(fields, uploadMetadataFields(req)...)
+			}
+			fields = append(fields, multipartFields...)
+			fields = append(fields, compressionFields...)
+			if chunkHasher != nil {
+				fields = append(fields, zap.Strings("body_chunk_hashes", chunkHasher.chunkHashes()))
+			}
+			for _, name := range config.RequestHeaderFields {
+				if value := req.Header.Get(name); value != "" {
+					fields = append(fields, zap.String("req."+strings.ToLower(name), value))
+				}
+			}
+			fields = append(fields, headerMapFields(req.Header, config.RequestHeaderFieldMap)...)
+			if config.SessionCookieName != "" {
+				_, cookieErr := req.Cookie(config.SessionCookieName)
+				fields = append(fields,
+					zap.Bool("has_session", cookieErr == nil),
+					zap.Int("cookie_count", len(req.Cookies())),
+				)
+			}
+			if config.LogJWTSubject && !isProduction(config.Environment) {
+				if sub, ok := jwtSubject(req.Header.Get(echo.HeaderAuthorization)); ok {
+					fields = append(fields, zap.String("jwt_sub", sub))
+				}
+			}
+			if config.LogHandlerName && !isProduction(config.Environment) {
+				fields = append(fields, handlerFields(c)...)
+			}
+			if len(config.LogContextKeys) > 0 && !isProduction(config.Environment) {
+				for _, key := range config.LogContextKeys {
+					if value := c.Get(key); value != nil {
+						fields = append(fields, zap.String("ctx_"+key, fmt.Sprint(value)))
+					}
+				}
+			}
+			fields = append(fields, redirectFields(status, responseHeader(res).Get(echo.HeaderLocation), req.Host)...)
+			fields = append(fields, rateLimitFields(status, responseHeader(res), err, time.Now())...)
+			if config.ParseServerTiming {
+				fields = append(fields, serverTimingFields(responseHeader(res).Values("Server-Timing"))...)
+			}
+			fields = append(fields, errorFields...)
+			fields = append(fields, hostFields...)
+			fields = append(fields, buildInfoFields()...)
+			fields = append(fields, state.snapshot()...)
+			fields = enforceMaxEntryBytes(fields, config.MaxEntryBytes)
+
+			if c.Path() == "/healthz" && status == 200 {
+				return handlerErr
+			}
+
+			level, message := accessLogLevel(status)
+			if level < config.MinLevel {
+				return handlerErr
+			}
+
+			if deduper != nil && status >= 400 {
+				signature := fmt.Sprintf("%d %s", status, c.Path())
+				if err != nil {
+					signature += " " + err.Error()
+				}
+				ok, suppressed := deduper.observe(signature)
+				if !ok {
+					return handlerErr
+				}
+				if suppressed > 0 {
+					fields = append(fields, zap.Int("suppressed_count", suppressed))
+				}
+			}
+			if config.MessageField != "" {
+				fields = append(fields, zap.String(config.MessageField, message))
 			}
 
-			if c.Path() == "/healthz" && res.Status == 200 {
-				return nil
+			zapFields := fields
+			if config.ZapFieldsFunc != nil {
+				zapFields = config.ZapFieldsFunc(append([]zapcore.Field(nil), fields...))
 			}
 
-			n := res.Status
-			switch {
-			case n >= 500:
-				log.Error("Server error", fields...)
-			case n >= 400:
-				log.Warn("Client error", fields...)
-			case n >= 300:
-				log.Info("Redirection", fields...)
-			default:
-				log.Info("Success", fields...)
+			log.Log(level, message, zapFields...)
+			if config.OnLog != nil {
+				entry := zapFieldsToMap(zapFields)
+				entry["level"] = level.String()
+				entry["message"] = message
+				config.OnLog(entry)
+			}
+			if config.OTelLogger != nil {
+				emitOTelRecord(c.Request().Context(), config.OTelLogger, level, message, zapFields)
 			}
 
-			if collection != nil {
-				go func(fields []zapcore.Field) {
-					fieldMap := zapFieldsToMap(fields)
+			entrySink := sink
+			if config.Sink == nil && config.CollectionResolver != nil {
+				entrySink = nil
+				if collection := config.CollectionResolver(c); collectionInitialized(collection) {
+					entrySink = mongoSink{collection: collection}
+				} else if collection != nil {
+					resolvedCollectionWarning.Do(func() {
+						log.Warn("Mongo collection returned by CollectionResolver is not initialized, its access logs will not be persisted")
+					})
+				}
+			}
+			if _, isMongo := entrySink.(mongoSink); isMongo && !MongoEnabled() {
+				entrySink = nil
+			}
+			if config.PersistSampledOnly && !trace.SpanFromContext(c.Request().Context()).SpanContext().IsSampled() {
+				entrySink = nil
+			}
 
-					insertCtx, insertCancel := context.WithTimeout(context.Background(), 5*time.Second)
-					defer insertCancel()
-					if err := mongoInsertFunc(insertCtx, collection, fieldMap); err != nil {
+			if entrySink != nil && (config.PersistOn == nil || config.PersistOn(status)) {
+				document := zapFieldsToMap(fields)
+				document[config.DateField] = primitive.NewDateTimeFromTime(end)
+				if config.MongoDocFunc != nil {
+					document = config.MongoDocFunc(document)
+				}
+
+				if config.SyncInsert {
+					if err := insertDocument(entrySink, document); err != nil {
 						log.Error("Error while inserting log to mongo", zap.Error(err))
+						if config.FailOnInsertError && handlerErr == nil {
+							return err
+						}
 					}
+				} else if acquireInsertSlot(insertSlots) {
+					go func(sink LogSink, document map[string]interface{}) {
+						defer releaseInsertSlot(insertSlots)
+						if err := insertDocument(sink, document); err != nil {
+							log.Error("Error while inserting log to mongo", zap.Error(err))
+						}
+					}(entrySink, document)
+				}
+			}
+
+			return handlerErr
+		}
+	}
+}
 
-				}(fields)
+func skipMethod(methods []string, method string) bool {
+	for _, candidate := range methods {
+		if strings.EqualFold(candidate, method) {
+			return true
+		}
+	}
+	return false
+}
+
+// debugBodyRequested reports whether the request opts into body logging through DebugBodyHeader
+func debugBodyRequested(req *http.Request, config *ZapLoggerConfig) bool {
+	if config.DebugBodyHeader == "" {
+		return false
+	}
+	if enabled, err := strconv.ParseBool(req.Header.Get(config.DebugBodyHeader)); err != nil || !enabled {
+		return false
+	}
+	return config.AllowDebugBodyInProduction || !isProduction(config.Environment)
+}
+
+// bodySampler returns the sampling draw for BodySampleRates, replaced in tests
+var bodySampler = rand.Float64
+
+// headerStringer renders a header as fmt's %v does, deferred until the field is encoded
+type headerStringer http.Header
+
+func (h headerStringer) String() string {
+	return fmt.Sprintf("%v", http.Header(h))
+}
+
+// cappedHeader renders a header like headerStringer after capping the values logged per key to
+// maxValues and each value to maxBytes. Dropped values and bytes are summarized as (+N more).
+type cappedHeader struct {
+	header    http.Header
+	maxValues int
+	maxBytes  int
+}
+
+func (h cappedHeader) String() string {
+	capped := make(http.Header, len(h.header))
+	for key, values := range h.header {
+		kept := values
+		if h.maxValues > 0 && len(values) > h.maxValues {
+			kept = values[:h.maxValues]
+		}
+		summarized := make([]string, 0, len(kept)+1)
+		for _, value := range kept {
+			if h.maxBytes > 0 && len(value) > h.maxBytes {
+				trimmed := trimPartialRune([]byte(value[:h.maxBytes]))
+				value = fmt.Sprintf("%s(+%d more)", trimmed, len(value)-len(trimmed))
 			}
+			summarized = append(summarized, value)
+		}
+		if dropped := len(values) - len(kept); dropped > 0 {
+			summarized = append(summarized, fmt.Sprintf("(+%d more)", dropped))
+		}
+		capped[key] = summarized
+	}
+	return fmt.Sprintf("%v", capped)
+}
 
-			return nil
+// headerMapFields returns the mapped fields of the present headers, ordered by field name
+func headerMapFields(header http.Header, mapping map[string]string) []zapcore.Field {
+	if len(mapping) == 0 {
+		return nil
+	}
+	var fields []zapcore.Field
+	for name, field := range mapping {
+		if value := header.Get(name); value != "" {
+			fields = append(fields, zap.String(field, value))
 		}
 	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Key < fields[j].Key })
+	return fields
+}
+
+// responseHeader returns the response header, or an empty header when the response has no writer
+func responseHeader(res *echo.Response) http.Header {
+	if res.Writer == nil {
+		return http.Header{}
+	}
+	return res.Header()
+}
+
+func generateRequestID() string {
+	return random.String(32)
+}
+
+// observedRequestID returns the request ID from the response header, falling back to the request header,
+// or the other way round when preferRequest is set.
+func observedRequestID(req *http.Request, res *echo.Response, preferRequest bool) string {
+	first, second := responseHeader(res), req.Header
+	if preferRequest {
+		first, second = second, first
+	}
+	if requestID := first.Get(RequestIDHeader()); requestID != "" {
+		return requestID
+	}
+	return second.Get(RequestIDHeader())
+}
+
+// ensureResponseRequestID sets the response request ID header from the request or a generated ID
+func ensureResponseRequestID(c echo.Context, generate func() string) {
+	header := RequestIDHeader()
+	if responseHeader(c.Response()).Get(header) != "" {
+		return
+	}
+	requestID := c.Request().Header.Get(header)
+	if requestID == "" {
+		requestID = generate()
+	}
+	c.Response().Header().Set(header, requestID)
+}
+
+// insertDocument sends the document to the sink with a timeout derived from the Init base context
+func insertDocument(sink LogSink, document map[string]interface{}) error {
+	insertCtx, insertCancel := context.WithTimeout(insertBaseContext(), 5*time.Second)
+	defer insertCancel()
+	return sink.Insert(insertCtx, document)
+}
+
+// statusOutcome returns the low-cardinality outcome label of the status class: ok, redirect,
+// client_error, or server_error
+func statusOutcome(status int) string {
+	switch {
+	case status >= 500:
+		return "server_error"
+	case status >= 400:
+		return "client_error"
+	case status >= 300:
+		return "redirect"
+	default:
+		return "ok"
+	}
+}
+
+// accessLogLevel returns the level and message of an access log entry for the status code
+func accessLogLevel(status int) (zapcore.Level, string) {
+	switch {
+	case status >= 500:
+		return zapcore.ErrorLevel, "Server error"
+	case status >= 400:
+		return zapcore.WarnLevel, "Client error"
+	case status >= 300:
+		return zapcore.InfoLevel, "Redirection"
+	default:
+		return zapcore.InfoLevel, "Success"
+	}
+}
+
+// startSlowRequestWatchdog logs an interim entry when the request outlives config.SlowRequestThreshold.
+// Correlation IDs are read up front so the timer never races with the handler.
+func startSlowRequestWatchdog(log *zap.Logger, c echo.Context, start time.Time, config *ZapLoggerConfig) *time.Timer {
+	req := c.Request()
+	requestID := observedRequestID(req, c.Response(), config.PreferRequestHeaderRequestID)
+	traceID, _ := correlationIDs(req.Context())
+	fields := []zapcore.Field{
+		zap.String("request_id", requestID),
+		zap.String("trace_id", traceID),
+		zap.String("method", req.Method),
+		zap.String("uri", loggedURI(req, config)),
+		zap.String("path", c.Path()),
+	}
+
+	return time.AfterFunc(config.SlowRequestThreshold, func() {
+		log.Warn("Request still running", append(fields, zap.String("elapsed", time.Since(start).String()))...)
+	})
+}
+
+// inFlight counts the requests currently being served by ZapLogger middlewares
+var inFlight atomic.Int64
+
+// InFlight returns the number of requests currently being served by ZapLogger middlewares
+func InFlight() int64 {
+	return inFlight.Load()
+}
+
+func serveInFlight(next echo.HandlerFunc, c echo.Context) error {
+	inFlight.Add(1)
+	defer inFlight.Add(-1)
+	return next(c)
+}
+
+// correlationIDs returns the W3C trace context IDs of the active span, 32 and 16 lowercase hex
+// characters, falling back to the IDs stored by LoggerWithContext when no valid span is present
+func correlationIDs(ctx context.Context) (traceID, spanID string) {
+	if spanContext := GetSpanFromContext(ctx).SpanContext(); spanContext.IsValid() {
+		return spanContext.TraceID().String(), spanContext.SpanID().String()
+	}
+	return GetTraceIDFromContext(ctx), GetSpanIDFromContext(ctx)
 }
 
 func GetSpanFromContext(ctx context.Context) trace.Span {
 	return trace.SpanFromContext(ctx)
 }
 
-func readAndResetBody(req *http.Request) ([]byte, error) {
-	bodyBytes, err := io.ReadAll(req.Body)
+// readAndResetBody reads the request body for logging and resets it so the handler reads it in full.
+// A positive limit reads at most limit bytes and reports whether the body was longer; the rest of the
+// stream stays unread behind the logged prefix, so large and chunked bodies are never fully buffered.
+// A body cut short of its Content-Length returns the bytes that arrived with io.ErrUnexpectedEOF, and the
+// handler reads the same bytes followed by the error.
+func readAndResetBody(req *http.Request, limit int) ([]byte, bool, error) {
+	if limit <= 0 {
+		bodyBytes, err := io.ReadAll(req.Body)
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			req.Body = prefixedBody{Reader: io.MultiReader(bytes.NewReader(bodyBytes), errReader{err: err}), Closer: req.Body}
+			return bodyBytes, false, err
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		req.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
+		return bodyBytes, false, nil
+	}
+
+	prefix, err := io.ReadAll(io.LimitReader(req.Body, int64(limit)+1))
+	var rest io.Reader = req.Body
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		rest = errReader{err: err}
+	} else if err != nil {
+		return nil, false, err
+	}
+	req.Body = prefixedBody{Reader: io.MultiReader(bytes.NewReader(prefix), rest), Closer: req.Body}
+	if len(prefix) <= limit {
+		return prefix, false, err
+	}
+	return trimPartialRune(prefix[:limit]), true, err
+}
+
+// contentLengthMismatch reports whether a declared Content-Length disagrees with the bytes read from the body.
+// A truncated read only proves a mismatch when the body outgrew a declared length within the limit.
+func contentLengthMismatch(declared int64, read int, truncated bool, limit int) bool {
+	if declared < 0 {
+		return false
+	}
+	if truncated {
+		return declared <= int64(limit)
+	}
+	return declared != int64(read)
+}
+
+// requestHasBody reports whether the request may carry a body. The server sets http.NoBody
+// on requests declaring neither a Content-Length nor a Transfer-Encoding.
+func requestHasBody(req *http.Request) bool {
+	return req.Body != nil && req.Body != http.NoBody
+}
+
+// prefixedBody replays the bytes read for logging before the unread remainder of a request body
+type prefixedBody struct {
+	io.Reader
+	io.Closer
+}
+
+// errReader fails every read with err, replaying a body read error to the handler after the logged prefix
+type errReader struct {
+	err error
+}
+
+func (r errReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
+
+// trimPartialRune drops a UTF-8 sequence cut short by truncation so text bodies stay valid UTF-8
+func trimPartialRune(b []byte) []byte {
+	for i := len(b) - 1; i >= 0 && i >= len(b)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(b[i]) {
+			if !utf8.FullRune(b[i:]) {
+				return b[:i]
+			}
+			break
+		}
+	}
+	return b
+}
+
+// entryOverflowFields lists the fields dropped, in order, when an entry exceeds MaxEntryBytes.
+// body_dump goes first as it repeats the header and both bodies.
+var entryOverflowFields = []string{"body_dump", "header", "response", "body"}
+
+func enforceMaxEntryBytes(fields []zapcore.Field, maxBytes int) []zapcore.Field {
+	if maxBytes <= 0 || entrySize(fields) <= maxBytes {
+		return fields
+	}
+	for _, key := range entryOverflowFields {
+		fields = dropField(fields, key)
+		if entrySize(fields) <= maxBytes {
+			break
+		}
+	}
+	return append(fields, zap.Bool("truncated", true))
+}
+
+// entrySize returns the JSON-encoded size of the fields
+func entrySize(fields []zapcore.Field) int {
+	encoder := zapcore.NewJSONEncoder(zapcore.EncoderConfig{})
+	buf, err := encoder.EncodeEntry(zapcore.Entry{}, fields)
 	if err != nil {
-		return nil, err
+		return 0
 	}
-	req.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
-	return bodyBytes, nil
+	defer buf.Free()
+	return buf.Len()
+}
+
+func dropField(fields []zapcore.Field, key string) []zapcore.Field {
+	kept := make([]zapcore.Field, 0, len(fields))
+	for _, field := range fields {
+		if field.Key != key {
+			kept = append(kept, field)
+		}
+	}
+	return kept
+}
+
+// loggedURI returns the request URI logged as uri, without its query under URIWithoutQuery and with
+// the RedactQueryParams values masked otherwise
+func loggedURI(req *http.Request, config *ZapLoggerConfig) string {
+	path, _, hasQuery := strings.Cut(req.RequestURI, "?")
+	if config.URIWithoutQuery || !hasQuery {
+		return path
+	}
+	if len(config.RedactQueryParams) == 0 {
+		return req.RequestURI
+	}
+	return path + "?" + redactQuery(req.URL.Query(), config.RedactQueryParams)
+}
+
+// redactQuery encodes values sorted by key with the values of the redacted params masked
+func redactQuery(values url.Values, redacted []string) string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var query strings.Builder
+	for _, key := range keys {
+		masked := false
+		for _, param := range redacted {
+			if strings.EqualFold(param, key) {
+				masked = true
+				break
+			}
+		}
+		for _, value := range values[key] {
+			if query.Len() > 0 {
+				query.WriteByte('&')
+			}
+			query.WriteString(url.QueryEscape(key))
+			query.WriteByte('=')
+			if masked {
+				query.WriteString(piiMask)
+			} else {
+				query.WriteString(url.QueryEscape(value))
+			}
+		}
+	}
+	return query.String()
+}
+
+// paramFields pairs route param names with values up to the shorter slice.
+// Leftovers from the longer slice, e.g. with wildcard routes, are logged under extra_params.
+func paramFields(names, values []string) []zapcore.Field {
+	n := len(names)
+	if len(values) < n {
+		n = len(values)
+	}
+	if len(names) == 0 && len(values) == 0 {
+		return nil
+	}
+
+	paired := make(map[string]string, n)
+	for i := 0; i < n; i++ {
+		paired[names[i]] = values[i]
+	}
+	fields := []zapcore.Field{zap.Any("params", paired)}
+
+	if len(names) != len(values) {
+		extra := append(append([]string(nil), names[n:]...), values[n:]...)
+		fields = append(fields, zap.Reflect("extra_params", extra))
+	}
+	return fields
+}
+
+// loggableBody returns body when it is valid UTF-8 and the formatted placeholder otherwise
+func loggableBody(body, placeholder string) string {
+	if utf8.ValidString(body) {
+		return body
+	}
+	if strings.Contains(placeholder, "%d") {
+		return fmt.Sprintf(placeholder, len(body))
+	}
+	return placeholder
+}
+
+// defaultMaxDecodedBodyBytes caps gzip decoding of responses, and of requests when MaxBodyLogBytes is unset
+const defaultMaxDecodedBodyBytes = 1 << 20
+
+func decodedBodyLimit(maxBodyLogBytes int) int {
+	if maxBodyLogBytes > 0 {
+		return maxBodyLogBytes
+	}
+	return defaultMaxDecodedBodyBytes
+}
+
+// decodeGzip decompresses b up to limit bytes, reporting whether the decoded output was cut at the limit
+func decodeGzip(b []byte, limit int) ([]byte, bool, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(b))
+	if err != nil {
+		return nil, false, err
+	}
+	defer reader.Close()
+	decoded, err := io.ReadAll(io.LimitReader(reader, int64(limit)+1))
+	if err != nil {
+		return nil, false, err
+	}
+	if len(decoded) <= limit {
+		return decoded, false, nil
+	}
+	return trimPartialRune(decoded[:limit]), true, nil
 }
 
 var mongoInsertFunc = func(ctx context.Context, collection *mongo.Collection, document interface{}) error {
 	if collection == nil {
 		return fmt.Errorf("collection is nil")
 	}
+	if !collectionInitialized(collection) {
+		return fmt.Errorf("collection is not initialized")
+	}
 	_, err := collection.InsertOne(ctx, document)
 	return err
 }
@@ -161,8 +1307,10 @@ func zapFieldsToMap(fields []zapcore.Field) map[string]interface{} {
 			fieldMap[field.Key] = field.String
 		case zapcore.Int64Type, zapcore.Int32Type, zapcore.Int16Type, zapcore.Int8Type, zapcore.Uint64Type, zapcore.Uint32Type, zapcore.Uint16Type, zapcore.Uint8Type:
 			fieldMap[field.Key] = field.Integer
-		case zapcore.Float64Type, zapcore.Float32Type:
-			fieldMap[field.Key] = float64(field.Integer)
+		case zapcore.Float64Type:
+			fieldMap[field.Key] = math.Float64frombits(uint64(field.Integer))
+		case zapcore.Float32Type:
+			fieldMap[field.Key] = float64(math.Float32frombits(uint32(field.Integer)))
 		case zapcore.BoolType:
 			fieldMap[field.Key] = field.Integer != 0
 		case zapcore.TimeType:
@@ -171,8 +1319,19 @@ func zapFieldsToMap(fields []zapcore.Field) map[string]interface{} {
 			fieldMap[field.Key] = field.Integer
 		case zapcore.ReflectType:
 			fieldMap[field.Key] = field.Interface
-		default:
+		case zapcore.StringerType:
+			fieldMap[field.Key] = field.Interface.(fmt.Stringer).String()
+		case zapcore.ErrorType:
+			fieldMap[field.Key] = field.Interface.(error).Error()
+		case zapcore.SkipType:
+		case zapcore.UnknownType:
 			fieldMap[field.Key] = field.String
+		default:
+			// Marshal through zap so other types keep their value, e.g. []interface{} for zap.Strings
+			// and []byte for zap.Binary
+			enc := zapcore.NewMapObjectEncoder()
+			field.AddTo(enc)
+			fieldMap[field.Key] = enc.Fields[field.Key]
 		}
 	}
 	return fieldMap
@@ -1,22 +1,34 @@
 package echomiddleware
 
 import (
+	"bufio"
 	"bytes"
+	"compress/gzip"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
-	"math"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"os"
+	"strings"
 	"sync"
 	"testing"
 	"time"
+	"unicode/utf8"
 
 	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -84,7 +96,7 @@ func TestZapLoggerLogsSuccessAndRestoresBody(t *testing.T) {
 	assert.Equal(t, int64(http.StatusCreated), contextFields["status"])
 	assert.Equal(t, "req-body", contextFields["body"])
 	assert.Equal(t, "response-body", contextFields["response"])
-	assert.Equal(t, "req-header-id", contextFields["request_id"])
+	assert.Equal(t, "resp-id", contextFields["request_id"])
 	assert.Equal(t, "/test/:id", contextFields["path"])
 	assert.Equal(t, "[123]", contextFields["param"])
 	assert.Equal(t, "foo=bar", contextFields["query"])
@@ -92,6 +104,34 @@ func TestZapLoggerLogsSuccessAndRestoresBody(t *testing.T) {
 	assert.Equal(t, "HTTP/1.1", contextFields["request_proto"])
 }
 
+func TestZapLoggerRequestIDPreference(t *testing.T) {
+	tests := []struct {
+		name          string
+		preferRequest bool
+		expected      string
+	}{
+		{name: "response-first", expected: "resp-id"},
+		{name: "request-first", preferRequest: true, expected: "req-header-id"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, c, _ := newTestContext(t, http.MethodGet, "/test/123", "")
+			c.Request().Header.Set(echo.HeaderXRequestID, "req-header-id")
+
+			core, obs := observer.New(zapcore.InfoLevel)
+			middleware := ZapLoggerWithConfig(ZapLoggerConfig{Logger: zap.New(core), PreferRequestHeaderRequestID: tc.preferRequest})
+			handler := middleware(func(c echo.Context) error {
+				c.Response().Header().Set(echo.HeaderXRequestID, "resp-id")
+				return c.NoContent(http.StatusOK)
+			})
+
+			require.NoError(t, handler(c))
+			require.Len(t, obs.All(), 1)
+			assert.Equal(t, tc.expected, obs.All()[0].ContextMap()["request_id"])
+		})
+	}
+}
+
 func TestZapLoggerRequestIDFallback(t *testing.T) {
 	_, c, _ := newTestContext(t, http.MethodGet, "/test/123", "")
 
@@ -136,6 +176,64 @@ func TestZapLoggerBodyReadError(t *testing.T) {
 	require.EqualError(t, err, readErr.Error())
 }
 
+func TestZapLoggerContentLengthMismatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		declared int64
+		expected interface{}
+	}{
+		{name: "matching", declared: 8, expected: nil},
+		{name: "shorter-body", declared: 64, expected: true},
+		{name: "undeclared", declared: -1, expected: nil},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, c, _ := newTestContext(t, http.MethodPost, "/test/123", "req-body")
+			c.Request().ContentLength = tc.declared
+
+			core, obs := observer.New(zapcore.InfoLevel)
+			handler := ZapLogger(zap.New(core), nil)(func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			})
+
+			require.NoError(t, handler(c))
+			require.Len(t, obs.All(), 1)
+			assert.Equal(t, tc.expected, obs.All()[0].ContextMap()["content_length_mismatch"])
+		})
+	}
+}
+
+func TestZapLoggerContentLengthMismatchShortBody(t *testing.T) {
+	core, obs := observer.New(zapcore.InfoLevel)
+	e := echo.New()
+	e.Use(ZapLogger(zap.New(core), nil))
+	var handlerErr error
+	e.POST("/upload", func(c echo.Context) error {
+		_, handlerErr = io.ReadAll(c.Request().Body)
+		return c.NoContent(http.StatusBadRequest)
+	})
+	server := httptest.NewServer(e)
+	t.Cleanup(server.Close)
+
+	conn, err := net.Dial("tcp", server.Listener.Addr().String())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	_, err = io.WriteString(conn, "POST /upload HTTP/1.1\r\nHost: example.local\r\nContent-Length: 64\r\n\r\nreq-body")
+	require.NoError(t, err)
+	require.NoError(t, conn.(*net.TCPConn).CloseWrite())
+
+	res, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	assert.ErrorIs(t, handlerErr, io.ErrUnexpectedEOF)
+
+	require.Eventually(t, func() bool { return obs.Len() == 1 }, time.Second, time.Millisecond)
+	fields := obs.All()[0].ContextMap()
+	assert.Equal(t, true, fields["content_length_mismatch"])
+	assert.Equal(t, "req-body", fields["body"])
+}
+
 func TestZapLoggerWebSocketUpgradeSkipsLogging(t *testing.T) {
 	_, c, _ := newTestContext(t, http.MethodGet, "/test/123", "")
 	c.Request().Header.Set("Connection", "Upgrade")
@@ -221,6 +319,41 @@ func TestZapLoggerHandlerErrorInvokesEchoErrorHandler(t *testing.T) {
 	require.EqualError(t, captured, "handler failed")
 }
 
+func TestZapLoggerErrorHandling(t *testing.T) {
+	t.Run("handled", func(t *testing.T) {
+		_, c, rec := newTestContext(t, http.MethodGet, "/test/123", "")
+
+		core, obs := observer.New(zapcore.InfoLevel)
+		middleware := ZapLoggerWithConfig(ZapLoggerConfig{Logger: zap.New(core)})
+		handler := middleware(func(c echo.Context) error {
+			return echo.NewHTTPError(http.StatusConflict, "duplicate")
+		})
+
+		require.NoError(t, handler(c))
+		assert.Equal(t, http.StatusConflict, rec.Code)
+		assert.Contains(t, rec.Body.String(), "duplicate")
+		require.Len(t, obs.All(), 1)
+		assert.Equal(t, int64(http.StatusConflict), obs.All()[0].ContextMap()["status"])
+	})
+
+	t.Run("propagated", func(t *testing.T) {
+		_, c, rec := newTestContext(t, http.MethodGet, "/test/123", "")
+
+		core, obs := observer.New(zapcore.InfoLevel)
+		middleware := ZapLoggerWithConfig(ZapLoggerConfig{Logger: zap.New(core), PropagateErrors: true})
+		handler := middleware(func(c echo.Context) error {
+			return errors.New("handler failed")
+		})
+
+		require.EqualError(t, handler(c), "handler failed")
+		assert.False(t, c.Response().Committed)
+		assert.Empty(t, rec.Body.String())
+		require.Len(t, obs.All(), 1)
+		assert.Equal(t, zapcore.ErrorLevel, obs.All()[0].Level)
+		assert.Equal(t, int64(http.StatusInternalServerError), obs.All()[0].ContextMap()["status"])
+	})
+}
+
 func TestZapLoggerMongoInsertion(t *testing.T) {
 	_, c, _ := newTestContext(t, http.MethodPost, "/test/123", "body")
 
@@ -246,7 +379,7 @@ func TestZapLoggerMongoInsertion(t *testing.T) {
 	core, obs := observer.New(zapcore.DebugLevel)
 	logger := zap.New(core)
 
-	middleware := ZapLogger(logger, &mongo.Collection{})
+	middleware := ZapLogger(logger, newTestCollection(t))
 	handler := middleware(func(c echo.Context) error {
 		return c.String(http.StatusInternalServerError, "boom")
 	})
@@ -266,6 +399,112 @@ func TestZapLoggerMongoInsertion(t *testing.T) {
 	assert.Equal(t, "body", collected["body"])
 }
 
+// newTestCollection returns a collection from a client that never connects, since the driver dials lazily
+func newTestCollection(t *testing.T) *mongo.Collection {
+	t.Helper()
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI("mongodb://127.0.0.1:1"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Disconnect(context.Background()) })
+	return client.Database("logs").Collection("access")
+}
+
+func TestZapLoggerCollectionResolverRoutesPerTenant(t *testing.T) {
+	originalInsert := mongoInsertFunc
+	t.Cleanup(func() { mongoInsertFunc = originalInsert })
+
+	var destinations []string
+	mongoInsertFunc = func(ctx context.Context, collection *mongo.Collection, document interface{}) error {
+		destinations = append(destinations, collection.Database().Name()+"."+collection.Name())
+		return nil
+	}
+
+	client := newTestCollection(t).Database().Client()
+	middleware := ZapLoggerWithConfig(ZapLoggerConfig{
+		Logger:     zap.NewNop(),
+		SyncInsert: true,
+		CollectionResolver: func(c echo.Context) *mongo.Collection {
+			tenant := c.Request().Header.Get("X-Tenant-ID")
+			if tenant == "" {
+				return nil
+			}
+			return client.Database("logs_" + tenant).Collection("access")
+		},
+	})
+	handler := middleware(func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	for _, tenant := range []string{"acme", "globex", ""} {
+		_, c, _ := newTestContext(t, http.MethodGet, "/test/123", "")
+		c.Request().Header.Set("X-Tenant-ID", tenant)
+		require.NoError(t, handler(c))
+	}
+
+	assert.Equal(t, []string{"logs_acme.access", "logs_globex.access"}, destinations)
+}
+
+func TestZapLoggerCollectionResolverUninitializedCollection(t *testing.T) {
+	originalInsert := mongoInsertFunc
+	t.Cleanup(func() { mongoInsertFunc = originalInsert })
+
+	var inserts int
+	mongoInsertFunc = func(ctx context.Context, collection *mongo.Collection, document interface{}) error {
+		inserts++
+		return nil
+	}
+
+	core, obs := observer.New(zapcore.InfoLevel)
+	middleware := ZapLoggerWithConfig(ZapLoggerConfig{
+		Logger:     zap.New(core),
+		SyncInsert: true,
+		CollectionResolver: func(echo.Context) *mongo.Collection {
+			return &mongo.Collection{}
+		},
+	})
+	handler := middleware(func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	for i := 0; i < 3; i++ {
+		_, c, _ := newTestContext(t, http.MethodGet, "/test/123", "")
+		require.NoError(t, handler(c))
+	}
+
+	assert.Zero(t, inserts)
+	assert.Equal(t, 1, obs.FilterMessage("Mongo collection returned by CollectionResolver is not initialized, its access logs will not be persisted").Len())
+	assert.Zero(t, obs.FilterMessage("Error while inserting log to mongo").Len())
+	assert.Equal(t, 3, obs.FilterMessage("Success").Len())
+}
+
+func TestZapLoggerUninitializedCollectionWarnsOnce(t *testing.T) {
+	originalInsert := mongoInsertFunc
+	t.Cleanup(func() { mongoInsertFunc = originalInsert })
+
+	var inserts int
+	mongoInsertFunc = func(ctx context.Context, collection *mongo.Collection, document interface{}) error {
+		inserts++
+		return nil
+	}
+
+	core, obs := observer.New(zapcore.DebugLevel)
+	middleware := ZapLoggerWithConfig(ZapLoggerConfig{Logger: zap.New(core), Collection: &mongo.Collection{}, SyncInsert: true})
+	handler := middleware(func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	for i := 0; i < 2; i++ {
+		_, c, _ := newTestContext(t, http.MethodGet, "/test/123", "")
+		require.NoError(t, handler(c))
+	}
+
+	assert.Zero(t, inserts)
+	warnings := obs.FilterMessage("Mongo collection is not initialized, access logs will not be persisted").All()
+	assert.Len(t, warnings, 1)
+	assert.Len(t, obs.FilterMessage("Success").All(), 2)
+
+	assert.EqualError(t, originalInsert(context.Background(), &mongo.Collection{}, bson.M{}), "collection is not initialized")
+}
+
 func TestZapFieldsToMapCoversAllTypes(t *testing.T) {
 	now := time.Unix(1, 0).UTC()
 	fields := []zapcore.Field{
@@ -273,10 +512,22 @@ func TestZapFieldsToMapCoversAllTypes(t *testing.T) {
 		zap.Int64("int", 7),
 		zap.Uint32("uint", 8),
 		zap.Float64("float", 3.14),
+		zap.Float32("float32", 1.5),
 		zap.Bool("bool", true),
 		zap.Time("time", now),
 		zap.Duration("duration", time.Second),
 		zap.Reflect("reflect", map[string]int{"a": 1}),
+		zap.Stringer("stringer", headerStringer{"Accept": {"*/*"}}),
+		zap.Strings("strings", []string{"a", "b"}),
+		zap.Any("any_strings", []string{"c"}),
+		zap.Object("object", zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+			enc.AddString("tenant", "acme")
+			return nil
+		})),
+		zap.NamedError("error", errors.New("boom")),
+		zap.Binary("binary", []byte{0xff, 0x01}),
+		zap.ByteString("byte_string", []byte("raw")),
+		zap.Skip(),
 		{Key: "default", String: "fallback"},
 	}
 
@@ -284,21 +535,1658 @@ func TestZapFieldsToMapCoversAllTypes(t *testing.T) {
 	assert.Equal(t, "value", result["string"])
 	assert.Equal(t, int64(7), result["int"])
 	assert.Equal(t, int64(8), result["uint"])
-	assert.Equal(t, float64(math.Float64bits(3.14)), result["float"])
+	assert.Equal(t, 3.14, result["float"])
+	assert.Equal(t, 1.5, result["float32"])
 	assert.Equal(t, true, result["bool"])
 	assert.Equal(t, time.Unix(0, now.UnixNano()).Format(time.RFC3339), result["time"])
 	assert.Equal(t, int64(time.Second), result["duration"])
 	assert.Equal(t, map[string]int{"a": 1}, result["reflect"])
+	assert.Equal(t, "map[Accept:[*/*]]", result["stringer"])
+	assert.Equal(t, []interface{}{"a", "b"}, result["strings"])
+	assert.Equal(t, []interface{}{"c"}, result["any_strings"])
+	assert.Equal(t, map[string]interface{}{"tenant": "acme"}, result["object"])
+	assert.Equal(t, "boom", result["error"])
+	assert.Equal(t, []byte{0xff, 0x01}, result["binary"])
+	assert.Equal(t, "raw", result["byte_string"])
+	assert.NotContains(t, result, "")
 	assert.Equal(t, "fallback", result["default"])
 }
 
-func TestGetSpanFromContext(t *testing.T) {
-	spanCtx := trace.NewSpanContext(trace.SpanContextConfig{
-		TraceID: trace.TraceID{1, 2, 3, 4, 5, 6, 7, 8, 8, 7, 6, 5, 4, 3, 2, 1},
-		SpanID:  trace.SpanID{1, 2, 3, 4, 5, 6, 7, 8},
+func TestZapLoggerURIWithoutQuery(t *testing.T) {
+	tests := []struct {
+		name            string
+		uriWithoutQuery bool
+		uri             string
+	}{
+		{name: "default", uri: "/test/123?token=secret"},
+		{name: "path-only", uriWithoutQuery: true, uri: "/test/123"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, c, _ := newTestContext(t, http.MethodGet, "/test/123?token=secret", "")
+
+			core, obs := observer.New(zapcore.InfoLevel)
+			middleware := ZapLoggerWithConfig(ZapLoggerConfig{Logger: zap.New(core), URIWithoutQuery: tc.uriWithoutQuery})
+			handler := middleware(func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			})
+			require.NoError(t, handler(c))
+
+			fields := obs.All()[0].ContextMap()
+			assert.Equal(t, tc.uri, fields["uri"])
+			assert.Equal(t, "token=secret", fields["query"])
+		})
+	}
+}
+
+func TestZapLoggerLatencyMilliseconds(t *testing.T) {
+	_, c, _ := newTestContext(t, http.MethodGet, "/test/123", "")
+
+	core, obs := observer.New(zapcore.InfoLevel)
+	middleware := ZapLoggerWithConfig(ZapLoggerConfig{Logger: zap.New(core), LatencyFormat: LatencyMilliseconds})
+	handler := middleware(func(c echo.Context) error {
+		time.Sleep(2 * time.Millisecond)
+		return c.NoContent(http.StatusOK)
 	})
-	ctx := trace.ContextWithSpanContext(context.Background(), spanCtx)
+	require.NoError(t, handler(c))
 
-	span := GetSpanFromContext(ctx)
-	assert.Equal(t, spanCtx, span.SpanContext())
+	latency, ok := obs.All()[0].ContextMap()["latency"].(float64)
+	require.True(t, ok)
+	assert.GreaterOrEqual(t, latency, 2.0)
+}
+
+func TestLatencyField(t *testing.T) {
+	latency := 1234567 * time.Nanosecond
+	assert.Equal(t, zap.String("latency", "1.234567ms"), latencyField(latency, LatencyString))
+	assert.Equal(t, zap.Float64("latency", 1.234567), latencyField(latency, LatencyMilliseconds))
+	assert.Equal(t, zap.Int64("latency", 1234), latencyField(latency, LatencyMicroseconds))
+}
+
+func TestZapLoggerPreservesHijacker(t *testing.T) {
+	core, obs := observer.New(zapcore.InfoLevel)
+
+	e := echo.New()
+	e.Use(ZapLogger(zap.New(core), nil))
+	e.GET("/raw", func(c echo.Context) error {
+		conn, rw, err := c.Response().Hijack()
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		_, _ = rw.WriteString("HTTP/1.1 200 OK\r\nContent-Length: 3\r\nConnection: close\r\n\r\nraw")
+		return rw.Flush()
+	})
+	server := httptest.NewServer(e)
+	t.Cleanup(server.Close)
+
+	res, err := http.Get(server.URL + "/raw")
+	require.NoError(t, err)
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "raw", string(body))
+
+	require.Eventually(t, func() bool { return obs.Len() == 1 }, time.Second, time.Millisecond)
+}
+
+func TestResponseWriterDelegatesOptionalInterfaces(t *testing.T) {
+	rec := httptest.NewRecorder()
+	writer := &responseWriter{Writer: rec, ResponseWriter: rec}
+
+	writer.Flush()
+	assert.True(t, rec.Flushed)
+
+	_, _, err := writer.Hijack()
+	assert.ErrorIs(t, err, http.ErrNotSupported)
+	assert.ErrorIs(t, writer.Push("/style.css", nil), http.ErrNotSupported)
+	assert.Same(t, rec, writer.Unwrap())
+}
+
+func TestZapLoggerLogHTTPVersion(t *testing.T) {
+	_, c, _ := newTestContext(t, http.MethodGet, "/test/123", "")
+	c.Request().Proto, c.Request().ProtoMajor, c.Request().ProtoMinor = "HTTP/2.0", 2, 0
+
+	core, obs := observer.New(zapcore.InfoLevel)
+	middleware := ZapLoggerWithConfig(ZapLoggerConfig{Logger: zap.New(core), LogHTTPVersion: true})
+	handler := middleware(func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+	require.NoError(t, handler(c))
+
+	fields := obs.All()[0].ContextMap()
+	assert.Equal(t, int64(2), fields["http_version"])
+	assert.Equal(t, "HTTP/2.0", fields["request_proto"])
+}
+
+func TestZapLoggerDivergentFieldHooks(t *testing.T) {
+	_, c, _ := newTestContext(t, http.MethodGet, "/test/123", "")
+
+	core, obs := observer.New(zapcore.InfoLevel)
+	sink := NewRingBufferSink(1)
+	middleware := ZapLoggerWithConfig(ZapLoggerConfig{
+		Logger:     zap.New(core),
+		Sink:       sink,
+		SyncInsert: true,
+		ZapFieldsFunc: func(fields []zapcore.Field) []zapcore.Field {
+			return dropField(fields, "header")
+		},
+		MongoDocFunc: func(document map[string]interface{}) map[string]interface{} {
+			document["header"] = map[string][]string(c.Request().Header)
+			return document
+		},
+	})
+	handler := middleware(func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+	require.NoError(t, handler(c))
+
+	assert.NotContains(t, obs.All()[0].ContextMap(), "header")
+	document := sink.Snapshot()[0]
+	assert.Equal(t, []string{"unit-agent"}, document["header"].(map[string][]string)["User-Agent"])
+	assert.Contains(t, document, "status")
+}
+
+func TestZapLoggerMaxBodyLogBytesChunked(t *testing.T) {
+	payload := strings.Repeat("0123456789", 1000)
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/upload", io.MultiReader(strings.NewReader(payload)))
+	req.TransferEncoding = []string{"chunked"}
+	require.Equal(t, int64(-1), req.ContentLength)
+	c := e.NewContext(req, httptest.NewRecorder())
+
+	core, obs := observer.New(zapcore.InfoLevel)
+	middleware := ZapLoggerWithConfig(ZapLoggerConfig{Logger: zap.New(core), MaxBodyLogBytes: 16})
+	handler := middleware(func(c echo.Context) error {
+		body, err := io.ReadAll(c.Request().Body)
+		if err != nil {
+			return err
+		}
+		assert.Equal(t, payload, string(body))
+		return c.NoContent(http.StatusAccepted)
+	})
+	require.NoError(t, handler(c))
+
+	fields := obs.All()[0].ContextMap()
+	assert.Equal(t, payload[:16], fields["body"])
+	assert.Equal(t, true, fields["body_truncated"])
+}
+
+func TestReadAndResetBodyWithinLimit(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("short"))
+	body, truncated, err := readAndResetBody(req, 16)
+	require.NoError(t, err)
+	assert.Equal(t, "short", string(body))
+	assert.False(t, truncated)
+
+	replayed, err := io.ReadAll(req.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "short", string(replayed))
+}
+
+func TestTrimPartialRune(t *testing.T) {
+	thai := []byte("สวัสดี")
+	assert.Equal(t, "ส", string(trimPartialRune(thai[:4])))
+	assert.Equal(t, "สว", string(trimPartialRune(thai[:6])))
+	assert.Equal(t, "abc", string(trimPartialRune([]byte("abc"))))
+}
+
+func TestZapLoggerDebugBodyHeader(t *testing.T) {
+	tests := []struct {
+		name        string
+		environment string
+		allowProd   bool
+		header      string
+		logged      bool
+	}{
+		{name: "dev-enabled", environment: "development", header: "1", logged: true},
+		{name: "dev-absent", environment: "development"},
+		{name: "dev-false", environment: "development", header: "0"},
+		{name: "production-ignored", environment: "production", header: "1"},
+		{name: "production-allowed", environment: "production", allowProd: true, header: "true", logged: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, c, _ := newTestContext(t, http.MethodPost, "/test/123", "body")
+			c.Request().Header.Set(echo.HeaderContentType, echo.MIMETextPlain)
+			if tc.header != "" {
+				c.Request().Header.Set("X-Debug-Body", tc.header)
+			}
+
+			core, obs := observer.New(zapcore.InfoLevel)
+			middleware := ZapLoggerWithConfig(ZapLoggerConfig{
+				Logger:                     zap.New(core),
+				Environment:                tc.environment,
+				LogBodyContentTypes:        []string{echo.MIMEApplicationJSON},
+				CaptureResponseOn:          StatusClasses(5),
+				DebugBodyHeader:            "X-Debug-Body",
+				AllowDebugBodyInProduction: tc.allowProd,
+			})
+			handler := middleware(func(c echo.Context) error {
+				return c.String(http.StatusOK, "response")
+			})
+			require.NoError(t, handler(c))
+
+			fields := obs.All()[0].ContextMap()
+			if tc.logged {
+				assert.Equal(t, "body", fields["body"])
+				assert.Equal(t, "response", fields["response"])
+			} else {
+				assert.Equal(t, "", fields["body"])
+				assert.Equal(t, "", fields["response"])
+			}
+		})
+	}
+}
+
+func TestZapLoggerCapsHeaderValues(t *testing.T) {
+	_, c, _ := newTestContext(t, http.MethodGet, "/test/123", "")
+	c.Request().Header = http.Header{
+		"X-Forwarded-For": {"10.0.0.1", "10.0.0.2", "10.0.0.3", "10.0.0.4", "10.0.0.5"},
+		"Cookie":          {strings.Repeat("a", 40)},
+		"X-Name":          {"aéééééé"},
+	}
+
+	core, obs := observer.New(zapcore.InfoLevel)
+	middleware := ZapLoggerWithConfig(ZapLoggerConfig{Logger: zap.New(core), MaxHeaderValues: 2, MaxHeaderValueBytes: 10})
+	handler := middleware(func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+	require.NoError(t, handler(c))
+
+	header := obs.All()[0].ContextMap()["header"]
+	assert.Equal(t,
+		"map[Cookie:[aaaaaaaaaa(+30 more)] X-Forwarded-For:[10.0.0.1 10.0.0.2 (+3 more)] X-Name:[aéééé(+4 more)]]",
+		header)
+	assert.True(t, utf8.ValidString(header.(string)))
+	assert.Len(t, c.Request().Header.Values("X-Forwarded-For"), 5)
+}
+
+func TestZapLoggerCountryHeader(t *testing.T) {
+	for _, country := range []string{"US", ""} {
+		_, c, _ := newTestContext(t, http.MethodGet, "/test/123", "")
+		if country != "" {
+			c.Request().Header.Set("CF-IPCountry", country)
+		}
+
+		core, obs := observer.New(zapcore.InfoLevel)
+		middleware := ZapLoggerWithConfig(ZapLoggerConfig{Logger: zap.New(core), CountryHeader: "CF-IPCountry"})
+		handler := middleware(func(c echo.Context) error {
+			return c.NoContent(http.StatusOK)
+		})
+		require.NoError(t, handler(c))
+
+		assert.Equal(t, country, obs.All()[0].ContextMap()["country"])
+	}
+}
+
+func TestZapLoggerSkipsBodyReadWithoutBody(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+	require.Equal(t, http.NoBody, req.Body)
+	c := e.NewContext(req, httptest.NewRecorder())
+
+	core, obs := observer.New(zapcore.InfoLevel)
+	middleware := ZapLogger(zap.New(core), nil)
+	handler := middleware(func(c echo.Context) error {
+		assert.Equal(t, http.NoBody, c.Request().Body)
+		return c.NoContent(http.StatusOK)
+	})
+	require.NoError(t, handler(c))
+
+	assert.Equal(t, "", obs.All()[0].ContextMap()["body"])
+}
+
+func TestRequestHasBody(t *testing.T) {
+	assert.False(t, requestHasBody(httptest.NewRequest(http.MethodGet, "/", nil)))
+	assert.False(t, requestHasBody(&http.Request{}))
+	assert.True(t, requestHasBody(httptest.NewRequest(http.MethodPost, "/", strings.NewReader("x"))))
+}
+
+func TestZapLoggerMinLevel(t *testing.T) {
+	core, obs := observer.New(zapcore.DebugLevel)
+	sink := NewRingBufferSink(4)
+	middleware := ZapLoggerWithConfig(ZapLoggerConfig{Logger: zap.New(core), Sink: sink, SyncInsert: true, MinLevel: zapcore.WarnLevel})
+
+	for _, status := range []int{http.StatusOK, http.StatusNotFound, http.StatusBadGateway} {
+		_, c, _ := newTestContext(t, http.MethodGet, "/test/123", "")
+		handler := middleware(func(c echo.Context) error {
+			return c.NoContent(status)
+		})
+		require.NoError(t, handler(c))
+	}
+
+	entries := obs.All()
+	require.Len(t, entries, 2)
+	assert.Equal(t, int64(http.StatusNotFound), entries[0].ContextMap()["status"])
+	assert.Equal(t, int64(http.StatusBadGateway), entries[1].ContextMap()["status"])
+	assert.Len(t, sink.Snapshot(), 2)
+}
+
+func TestZapLoggerMessageField(t *testing.T) {
+	core, obs := observer.New(zapcore.InfoLevel)
+	middleware := ZapLoggerWithConfig(ZapLoggerConfig{Logger: zap.New(core), MessageField: "event"})
+
+	statuses := []int{http.StatusOK, http.StatusFound, http.StatusNotFound, http.StatusBadGateway}
+	for _, status := range statuses {
+		_, c, _ := newTestContext(t, http.MethodGet, "/test/123", "")
+		handler := middleware(func(c echo.Context) error {
+			return c.NoContent(status)
+		})
+		require.NoError(t, handler(c))
+	}
+
+	entries := obs.All()
+	require.Len(t, entries, len(statuses))
+	for i, expected := range []string{"Success", "Redirection", "Client error", "Server error"} {
+		assert.Equal(t, expected, entries[i].ContextMap()["event"])
+		assert.Equal(t, expected, entries[i].Message)
+	}
+}
+
+func TestZapLoggerResponseNotCaptured(t *testing.T) {
+	_, c, rec := newTestContext(t, http.MethodGet, "/test/123", "")
+	stale := c.Response().Writer
+
+	core, obs := observer.New(zapcore.InfoLevel)
+	middleware := ZapLogger(zap.New(core), nil)
+	handler := middleware(func(c echo.Context) error {
+		c.Response().Writer = stale
+		return c.String(http.StatusOK, "bypassed")
+	})
+
+	require.NoError(t, handler(c))
+	assert.Equal(t, "bypassed", rec.Body.String())
+
+	require.Len(t, obs.All(), 1)
+	assert.Equal(t, "<not captured>", obs.All()[0].ContextMap()["response"])
+}
+
+func TestZapLoggerLogContextKeys(t *testing.T) {
+	tests := []struct {
+		name        string
+		environment string
+		expected    interface{}
+	}{
+		{name: "development", environment: "development", expected: "bar"},
+		{name: "production", environment: "production"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, c, _ := newTestContext(t, http.MethodGet, "/test/123", "")
+
+			core, obs := observer.New(zapcore.InfoLevel)
+			middleware := ZapLoggerWithConfig(ZapLoggerConfig{
+				Logger:         zap.New(core),
+				Environment:    tc.environment,
+				LogContextKeys: []string{"foo", "missing"},
+			})
+			handler := middleware(func(c echo.Context) error {
+				c.Set("foo", "bar")
+				return c.NoContent(http.StatusOK)
+			})
+
+			require.NoError(t, handler(c))
+			require.Len(t, obs.All(), 1)
+			fields := obs.All()[0].ContextMap()
+			assert.Equal(t, tc.expected, fields["ctx_foo"])
+			assert.NotContains(t, fields, "ctx_missing")
+		})
+	}
+}
+
+func TestZapLoggerOutcome(t *testing.T) {
+	tests := []struct {
+		status   int
+		expected string
+	}{
+		{status: http.StatusOK, expected: "ok"},
+		{status: http.StatusMovedPermanently, expected: "redirect"},
+		{status: http.StatusConflict, expected: "client_error"},
+		{status: http.StatusServiceUnavailable, expected: "server_error"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.expected, func(t *testing.T) {
+			_, c, _ := newTestContext(t, http.MethodGet, "/test/123", "")
+
+			core, obs := observer.New(zapcore.InfoLevel)
+			sink := &recordingSink{}
+			middleware := ZapLoggerWithConfig(ZapLoggerConfig{Logger: zap.New(core), Sink: sink, SyncInsert: true})
+			handler := middleware(func(c echo.Context) error {
+				return c.NoContent(tc.status)
+			})
+
+			require.NoError(t, handler(c))
+			require.Len(t, obs.All(), 1)
+			assert.Equal(t, tc.expected, obs.All()[0].ContextMap()["outcome"])
+			require.Len(t, sink.documents, 1)
+			assert.Equal(t, tc.expected, sink.documents[0]["outcome"])
+		})
+	}
+}
+
+func TestZapLoggerDecodeGzipRequest(t *testing.T) {
+	payload := strings.Repeat(`{"event":"click"}`, 50)
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	_, err := gz.Write([]byte(payload))
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+
+	_, c, _ := newTestContext(t, http.MethodPost, "/test/123", compressed.String())
+	c.Request().Header.Set(echo.HeaderContentEncoding, "gzip")
+
+	core, obs := observer.New(zapcore.InfoLevel)
+	middleware := ZapLoggerWithConfig(ZapLoggerConfig{Logger: zap.New(core), DecodeGzipRequest: true})
+	handler := middleware(func(c echo.Context) error {
+		body, err := io.ReadAll(c.Request().Body)
+		if err != nil {
+			return err
+		}
+		assert.Equal(t, compressed.Bytes(), body)
+		return c.NoContent(http.StatusAccepted)
+	})
+	require.NoError(t, handler(c))
+
+	fields := obs.All()[0].ContextMap()
+	assert.Equal(t, payload, fields["body"])
+	assert.Equal(t, int64(compressed.Len()), fields["body_compressed_bytes"])
+	assert.Equal(t, int64(len(payload)), fields["body_decompressed_bytes"])
+}
+
+func TestZapLoggerDecodeGzipRequestCapsOutput(t *testing.T) {
+	payload := strings.Repeat("a", 8<<20)
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	_, err := gz.Write([]byte(payload))
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+
+	tests := []struct {
+		name     string
+		maxBytes int
+		expected int
+	}{
+		{name: "default-cap", expected: defaultMaxDecodedBodyBytes},
+		{name: "max-body-log-bytes", maxBytes: 64 << 10, expected: 64 << 10},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, c, _ := newTestContext(t, http.MethodPost, "/test/123", compressed.String())
+			c.Request().Header.Set(echo.HeaderContentEncoding, "gzip")
+
+			core, obs := observer.New(zapcore.InfoLevel)
+			middleware := ZapLoggerWithConfig(ZapLoggerConfig{Logger: zap.New(core), DecodeGzipRequest: true, MaxBodyLogBytes: tc.maxBytes})
+			handler := middleware(func(c echo.Context) error {
+				return c.NoContent(http.StatusAccepted)
+			})
+			require.NoError(t, handler(c))
+
+			fields := obs.All()[0].ContextMap()
+			assert.Len(t, fields["body"], tc.expected)
+			assert.Equal(t, true, fields["body_truncated"])
+			assert.Equal(t, int64(compressed.Len()), fields["body_compressed_bytes"])
+			assert.NotContains(t, fields, "body_decompressed_bytes")
+		})
+	}
+}
+
+func TestZapLoggerSkipMethods(t *testing.T) {
+	core, obs := observer.New(zapcore.InfoLevel)
+	sink := NewRingBufferSink(2)
+	middleware := ZapLoggerWithConfig(ZapLoggerConfig{Logger: zap.New(core), Sink: sink, SyncInsert: true, SkipMethods: []string{"options"}})
+	handler := middleware(func(c echo.Context) error {
+		return c.NoContent(http.StatusNoContent)
+	})
+
+	for _, method := range []string{http.MethodOptions, http.MethodGet} {
+		_, c, _ := newTestContext(t, method, "/test/123", "")
+		require.NoError(t, handler(c))
+	}
+
+	require.Len(t, obs.All(), 1)
+	assert.Equal(t, http.MethodGet, obs.All()[0].ContextMap()["method"])
+	assert.Len(t, sink.Snapshot(), 1)
+}
+
+func TestZapLoggerOnLog(t *testing.T) {
+	entries := make(chan map[string]interface{}, 2)
+	middleware := ZapLoggerWithConfig(ZapLoggerConfig{
+		Logger: zap.NewNop(),
+		OnLog: func(entry map[string]interface{}) {
+			entries <- entry
+		},
+	})
+
+	for _, status := range []int{http.StatusOK, http.StatusNotFound} {
+		_, c, _ := newTestContext(t, http.MethodGet, "/test/123", "")
+		handler := middleware(func(c echo.Context) error {
+			return c.NoContent(status)
+		})
+		require.NoError(t, handler(c))
+	}
+
+	require.Len(t, entries, 2)
+	first := <-entries
+	assert.Equal(t, int64(http.StatusOK), first["status"])
+	assert.Equal(t, "info", first["level"])
+	assert.Equal(t, "Success", first["message"])
+	second := <-entries
+	assert.Equal(t, int64(http.StatusNotFound), second["status"])
+	assert.Equal(t, "warn", second["level"])
+}
+
+func TestZapLoggerOmitHeader(t *testing.T) {
+	for _, omit := range []bool{false, true} {
+		_, c, _ := newTestContext(t, http.MethodGet, "/test/123", "")
+		c.Request().Header = http.Header{"X-Test": {"1"}}
+
+		core, obs := observer.New(zapcore.InfoLevel)
+		sink := NewRingBufferSink(1)
+		middleware := ZapLoggerWithConfig(ZapLoggerConfig{Logger: zap.New(core), Sink: sink, SyncInsert: true, OmitHeader: omit})
+		handler := middleware(func(c echo.Context) error {
+			return c.NoContent(http.StatusOK)
+		})
+		require.NoError(t, handler(c))
+
+		fields := obs.All()[0].ContextMap()
+		document := sink.Snapshot()[0]
+		if omit {
+			assert.NotContains(t, fields, "header")
+			assert.NotContains(t, document, "header")
+		} else {
+			assert.Equal(t, "map[X-Test:[1]]", fields["header"])
+			assert.Equal(t, "map[X-Test:[1]]", document["header"])
+		}
+	}
+}
+
+func TestGetSpanFromContext(t *testing.T) {
+	spanCtx := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{1, 2, 3, 4, 5, 6, 7, 8, 8, 7, 6, 5, 4, 3, 2, 1},
+		SpanID:  trace.SpanID{1, 2, 3, 4, 5, 6, 7, 8},
+	})
+	ctx := trace.ContextWithSpanContext(context.Background(), spanCtx)
+
+	span := GetSpanFromContext(ctx)
+	assert.Equal(t, spanCtx, span.SpanContext())
+}
+
+func TestZapLoggerCaptureResponseOn(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		response string
+	}{
+		{name: "success-discarded", status: http.StatusOK, response: ""},
+		{name: "server-error-logged", status: http.StatusInternalServerError, response: "body"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, c, rec := newTestContext(t, http.MethodGet, "/test/123", "")
+
+			core, obs := observer.New(zapcore.DebugLevel)
+			middleware := ZapLoggerWithConfig(ZapLoggerConfig{
+				Logger:            zap.New(core),
+				CaptureResponseOn: StatusClasses(4, 5),
+			})
+			handler := middleware(func(c echo.Context) error {
+				return c.String(tc.status, "body")
+			})
+
+			require.NoError(t, handler(c))
+			assert.Equal(t, "body", rec.Body.String())
+
+			entries := obs.All()
+			require.Len(t, entries, 1)
+			assert.Equal(t, tc.response, entries[0].ContextMap()["response"])
+		})
+	}
+}
+
+func TestZapLoggerCaptureBodyOn(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+	}{
+		{name: "success-discarded", status: http.StatusOK, body: ""},
+		{name: "client-error-logged", status: http.StatusBadRequest, body: `{"name":"x"}`},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, c, _ := newTestContext(t, http.MethodPost, "/test/123", `{"name":"x"}`)
+
+			core, obs := observer.New(zapcore.DebugLevel)
+			middleware := ZapLoggerWithConfig(ZapLoggerConfig{
+				Logger:        zap.New(core),
+				CaptureBodyOn: StatusClasses(4, 5),
+			})
+			handler := middleware(func(c echo.Context) error {
+				body, err := io.ReadAll(c.Request().Body)
+				require.NoError(t, err)
+				assert.Equal(t, `{"name":"x"}`, string(body))
+				return c.NoContent(tc.status)
+			})
+
+			require.NoError(t, handler(c))
+
+			entries := obs.All()
+			require.Len(t, entries, 1)
+			assert.Equal(t, tc.body, entries[0].ContextMap()["body"])
+		})
+	}
+}
+
+func TestZapLoggerJSONOutput(t *testing.T) {
+	_, c, _ := newTestContext(t, http.MethodGet, "/test/123", "")
+
+	var console, output bytes.Buffer
+	consoleLogger := zap.New(zapcore.NewCore(
+		zapcore.NewConsoleEncoder(zap.NewDevelopmentEncoderConfig()),
+		zapcore.AddSync(&console),
+		zapcore.InfoLevel,
+	))
+
+	middleware := ZapLoggerWithConfig(ZapLoggerConfig{Logger: consoleLogger, JSONOutput: zapcore.AddSync(&output)})
+	handler := middleware(func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	require.NoError(t, handler(c))
+
+	assert.Empty(t, console.String())
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal(output.Bytes(), &entry))
+	assert.Equal(t, "Success", entry["msg"])
+	assert.Equal(t, float64(http.StatusOK), entry["status"])
+	assert.Equal(t, "/test/:id", entry["path"])
+}
+
+func TestZapLoggerBodySampleRates(t *testing.T) {
+	originalSampler := bodySampler
+	t.Cleanup(func() { bodySampler = originalSampler })
+
+	draws := []float64{0.005, 0.5, 0.009, 0.9}
+	bodySampler = func() float64 {
+		draw := draws[0]
+		draws = draws[1:]
+		return draw
+	}
+
+	core, obs := observer.New(zapcore.InfoLevel)
+	middleware := ZapLoggerWithConfig(ZapLoggerConfig{
+		Logger:          zap.New(core),
+		BodySampleRates: map[string]float64{"/test/:id": 0.01},
+	})
+	handler := middleware(func(c echo.Context) error {
+		return c.String(http.StatusOK, "response")
+	})
+
+	for i := 0; i < 4; i++ {
+		_, c, rec := newTestContext(t, http.MethodPost, "/test/123", "body")
+		require.NoError(t, handler(c))
+		assert.Equal(t, "response", rec.Body.String())
+	}
+
+	entries := obs.All()
+	require.Len(t, entries, 4)
+	var sampled int
+	for _, entry := range entries {
+		fields := entry.ContextMap()
+		if fields["body"] == "body" {
+			assert.Equal(t, "response", fields["response"])
+			sampled++
+		} else {
+			assert.Equal(t, "", fields["body"])
+			assert.Equal(t, "", fields["response"])
+		}
+	}
+	assert.Equal(t, 2, sampled)
+	assert.Empty(t, draws)
+}
+
+func TestZapLoggerHeadRequestSkipsResponseCapture(t *testing.T) {
+	_, c, rec := newTestContext(t, http.MethodHead, "/test/123", "")
+
+	core, obs := observer.New(zapcore.InfoLevel)
+	middleware := ZapLogger(zap.New(core), nil)
+	handler := middleware(func(c echo.Context) error {
+		assert.Same(t, rec, c.Response().Writer)
+		time.Sleep(time.Millisecond)
+		return c.NoContent(http.StatusNoContent)
+	})
+
+	require.NoError(t, handler(c))
+
+	require.Len(t, obs.All(), 1)
+	fields := obs.All()[0].ContextMap()
+	assert.Equal(t, int64(http.StatusNoContent), fields["status"])
+	assert.Equal(t, http.MethodHead, fields["method"])
+	assert.Equal(t, "", fields["response"])
+	latency, err := time.ParseDuration(fields["latency"].(string))
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, latency, time.Millisecond)
+}
+
+func TestZapLoggerNilResponseWriter(t *testing.T) {
+	_, c, _ := newTestContext(t, http.MethodPost, "/test/123", "body")
+	c.Response().Writer = nil
+
+	core, obs := observer.New(zapcore.InfoLevel)
+	middleware := ZapLoggerWithConfig(ZapLoggerConfig{
+		Logger:                  zap.New(core),
+		EnsureResponseRequestID: true,
+		IncludeBodyDump:         true,
+	})
+	handler := middleware(func(c echo.Context) error {
+		assert.Nil(t, c.Response().Writer)
+		return nil
+	})
+
+	assert.NotPanics(t, func() {
+		require.NoError(t, handler(c))
+	})
+
+	require.Len(t, obs.All(), 1)
+	fields := obs.All()[0].ContextMap()
+	assert.Equal(t, "body", fields["body"])
+	assert.Equal(t, "", fields["response"])
+}
+
+func TestStatusClasses(t *testing.T) {
+	match := StatusClasses(4, 5)
+	assert.False(t, match(http.StatusOK))
+	assert.False(t, match(http.StatusFound))
+	assert.True(t, match(http.StatusBadRequest))
+	assert.True(t, match(http.StatusServiceUnavailable))
+}
+
+func TestZapLoggerDecodesGzipResponse(t *testing.T) {
+	_, c, rec := newTestContext(t, http.MethodGet, "/test/123", "")
+	c.Request().Header.Set(echo.HeaderAcceptEncoding, "gzip")
+
+	core, obs := observer.New(zapcore.InfoLevel)
+	zapLogger := ZapLoggerWithConfig(ZapLoggerConfig{Logger: zap.New(core), DecodeGzipResponse: true})
+	handler := zapLogger(middleware.Gzip()(func(c echo.Context) error {
+		return c.String(http.StatusOK, "plain response")
+	}))
+
+	require.NoError(t, handler(c))
+	require.Equal(t, "gzip", rec.Header().Get(echo.HeaderContentEncoding))
+
+	entries := obs.All()
+	require.Len(t, entries, 1)
+	assert.Equal(t, "plain response", entries[0].ContextMap()["response"])
+	assert.NotContains(t, entries[0].ContextMap(), "response_truncated")
+}
+
+func TestZapLoggerDecodesGzipResponseCapsOutput(t *testing.T) {
+	_, c, _ := newTestContext(t, http.MethodGet, "/test/123", "")
+	c.Request().Header.Set(echo.HeaderAcceptEncoding, "gzip")
+
+	core, obs := observer.New(zapcore.InfoLevel)
+	zapLogger := ZapLoggerWithConfig(ZapLoggerConfig{Logger: zap.New(core), DecodeGzipResponse: true, MaxBodyLogBytes: 16})
+	handler := zapLogger(middleware.Gzip()(func(c echo.Context) error {
+		return c.String(http.StatusOK, strings.Repeat("r", 2<<20))
+	}))
+
+	require.NoError(t, handler(c))
+
+	fields := obs.All()[0].ContextMap()
+	assert.Len(t, fields["response"], defaultMaxDecodedBodyBytes)
+	assert.Equal(t, true, fields["response_truncated"])
+}
+
+func TestZapLoggerBinaryBodyPlaceholder(t *testing.T) {
+	tests := []struct {
+		name        string
+		placeholder string
+		expected    string
+	}{
+		{name: "default", placeholder: "", expected: "<binary 4 bytes>"},
+		{name: "custom", placeholder: "[binary]", expected: "[binary]"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, c, _ := newTestContext(t, http.MethodPost, "/test/123", "\xff\xfe\x00\x01")
+
+			core, obs := observer.New(zapcore.InfoLevel)
+			middleware := ZapLoggerWithConfig(ZapLoggerConfig{
+				Logger:                zap.New(core),
+				BinaryBodyPlaceholder: tc.placeholder,
+			})
+			handler := middleware(func(c echo.Context) error {
+				return c.Blob(http.StatusOK, echo.MIMEOctetStream, []byte{0xc3, 0x28})
+			})
+
+			require.NoError(t, handler(c))
+
+			entries := obs.All()
+			require.Len(t, entries, 1)
+			fields := entries[0].ContextMap()
+			assert.Equal(t, tc.expected, fields["body"])
+			if tc.placeholder == "" {
+				assert.Equal(t, "<binary 2 bytes>", fields["response"])
+			}
+		})
+	}
+}
+
+func TestZapLoggerIncludeHostMetadata(t *testing.T) {
+	_, c, _ := newTestContext(t, http.MethodGet, "/test/123", "")
+
+	core, obs := observer.New(zapcore.InfoLevel)
+	sink := NewRingBufferSink(1)
+	middleware := ZapLoggerWithConfig(ZapLoggerConfig{
+		Logger:              zap.New(core),
+		Sink:                sink,
+		IncludeHostMetadata: true,
+	})
+	handler := middleware(func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	require.NoError(t, handler(c))
+
+	hostname, err := os.Hostname()
+	require.NoError(t, err)
+
+	entries := obs.All()
+	require.Len(t, entries, 1)
+	fields := entries[0].ContextMap()
+	assert.Equal(t, hostname, fields["hostname"])
+	assert.Equal(t, int64(os.Getpid()), fields["pid"])
+
+	require.Eventually(t, func() bool { return len(sink.Snapshot()) == 1 }, time.Second, time.Millisecond)
+	assert.Equal(t, hostname, sink.Snapshot()[0]["hostname"])
+	assert.Equal(t, int64(os.Getpid()), sink.Snapshot()[0]["pid"])
+}
+
+func TestZapLoggerOmitsHostMetadataByDefault(t *testing.T) {
+	_, c, _ := newTestContext(t, http.MethodGet, "/test/123", "")
+
+	core, obs := observer.New(zapcore.InfoLevel)
+	handler := ZapLogger(zap.New(core), nil)(func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	require.NoError(t, handler(c))
+	require.Len(t, obs.All(), 1)
+	assert.NotContains(t, obs.All()[0].ContextMap(), "hostname")
+	assert.NotContains(t, obs.All()[0].ContextMap(), "pid")
+}
+
+func TestZapLoggerLogsHTTPError(t *testing.T) {
+	t.Run("default-error-handler", func(t *testing.T) {
+		_, c, rec := newTestContext(t, http.MethodPost, "/test/123", "")
+
+		core, obs := observer.New(zapcore.DebugLevel)
+		handler := ZapLogger(zap.New(core), nil)(func(c echo.Context) error {
+			return echo.NewHTTPError(http.StatusBadRequest, "bad input")
+		})
+
+		require.NoError(t, handler(c))
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+		entries := obs.All()
+		require.Len(t, entries, 1)
+		assert.Equal(t, zapcore.WarnLevel, entries[0].Level)
+		fields := entries[0].ContextMap()
+		assert.Equal(t, int64(http.StatusBadRequest), fields["error_code"])
+		assert.Equal(t, "bad input", fields["error_message"])
+	})
+
+	t.Run("uncommitted-response", func(t *testing.T) {
+		e, c, _ := newTestContext(t, http.MethodPost, "/test/123", "")
+		e.HTTPErrorHandler = func(err error, c echo.Context) {}
+
+		core, obs := observer.New(zapcore.DebugLevel)
+		handler := ZapLogger(zap.New(core), nil)(func(c echo.Context) error {
+			return echo.NewHTTPError(http.StatusBadRequest, "bad input")
+		})
+
+		require.NoError(t, handler(c))
+
+		entries := obs.All()
+		require.Len(t, entries, 1)
+		assert.Equal(t, "Client error", entries[0].Message)
+		assert.Equal(t, zapcore.WarnLevel, entries[0].Level)
+		assert.Equal(t, int64(http.StatusBadRequest), entries[0].ContextMap()["status"])
+	})
+}
+
+func TestZapLoggerRequestHeaderFields(t *testing.T) {
+	_, c, _ := newTestContext(t, http.MethodGet, "/test/123", "")
+	c.Request().Header.Set("X-Tenant-ID", "tenant-a")
+	c.Request().Header.Set("X-Client-Version", "2.4.1")
+
+	core, obs := observer.New(zapcore.InfoLevel)
+	middleware := ZapLoggerWithConfig(ZapLoggerConfig{
+		Logger:              zap.New(core),
+		RequestHeaderFields: []string{"X-Tenant-ID", "X-Client-Version", "X-Missing"},
+	})
+	handler := middleware(func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	require.NoError(t, handler(c))
+
+	entries := obs.All()
+	require.Len(t, entries, 1)
+	fields := entries[0].ContextMap()
+	assert.Equal(t, "tenant-a", fields["req.x-tenant-id"])
+	assert.Equal(t, "2.4.1", fields["req.x-client-version"])
+	assert.NotContains(t, fields, "req.x-missing")
+}
+
+func TestZapLoggerRequestHeaderFieldMap(t *testing.T) {
+	_, c, _ := newTestContext(t, http.MethodPost, "/test/123", "")
+	c.Request().Header.Set("Idempotency-Key", "idem-7f3a")
+	c.Request().Header.Set("X-Retry-Count", "2")
+
+	core, obs := observer.New(zapcore.InfoLevel)
+	middleware := ZapLoggerWithConfig(ZapLoggerConfig{
+		Logger: zap.New(core),
+		RequestHeaderFieldMap: map[string]string{
+			"Idempotency-Key": "idempotency_key",
+			"X-Retry-Count":   "retry_count",
+			"X-Attempt":       "attempt",
+		},
+	})
+	handler := middleware(func(c echo.Context) error {
+		return c.NoContent(http.StatusCreated)
+	})
+
+	require.NoError(t, handler(c))
+
+	entries := obs.All()
+	require.Len(t, entries, 1)
+	fields := entries[0].ContextMap()
+	assert.Equal(t, "idem-7f3a", fields["idempotency_key"])
+	assert.Equal(t, "2", fields["retry_count"])
+	assert.NotContains(t, fields, "attempt")
+}
+
+type mismatchedParamsContext struct {
+	echo.Context
+	names  []string
+	values []string
+}
+
+func (c *mismatchedParamsContext) ParamNames() []string  { return c.names }
+func (c *mismatchedParamsContext) ParamValues() []string { return c.values }
+
+func TestZapLoggerParamsMismatchedLengths(t *testing.T) {
+	tests := []struct {
+		name   string
+		names  []string
+		values []string
+		params map[string]string
+		extra  []string
+	}{
+		{name: "matched", names: []string{"id", "*"}, values: []string{"1", "a/b"}, params: map[string]string{"id": "1", "*": "a/b"}},
+		{name: "extra-values", names: []string{"*"}, values: []string{"a/b", "c"}, params: map[string]string{"*": "a/b"}, extra: []string{"c"}},
+		{name: "extra-names", names: []string{"id", "*"}, values: []string{"1"}, params: map[string]string{"id": "1"}, extra: []string{"*"}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, c, _ := newTestContext(t, http.MethodGet, "/files/a/b", "")
+			c.SetPath("/files/*")
+			ctx := &mismatchedParamsContext{Context: c, names: tc.names, values: tc.values}
+
+			core, obs := observer.New(zapcore.InfoLevel)
+			handler := ZapLogger(zap.New(core), nil)(func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			})
+
+			require.NotPanics(t, func() { require.NoError(t, handler(ctx)) })
+
+			entries := obs.All()
+			require.Len(t, entries, 1)
+			fields := entries[0].ContextMap()
+			assert.Equal(t, tc.params, fields["params"])
+			if tc.extra == nil {
+				assert.NotContains(t, fields, "extra_params")
+			} else {
+				assert.Equal(t, tc.extra, fields["extra_params"])
+			}
+		})
+	}
+}
+
+func TestZapLoggerInFlight(t *testing.T) {
+	before := InFlight()
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	handler := ZapLogger(zap.NewNop(), nil)(func(c echo.Context) error {
+		started <- struct{}{}
+		<-release
+		return c.NoContent(http.StatusOK)
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		_, c, _ := newTestContext(t, http.MethodGet, "/test/123", "")
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, handler(c))
+		}()
+		<-started
+	}
+
+	assert.Equal(t, before+3, InFlight())
+	close(release)
+	wg.Wait()
+	assert.Equal(t, before, InFlight())
+}
+
+func TestZapLoggerForEnvironmentSelectsSink(t *testing.T) {
+	tests := []struct {
+		name      string
+		env       string
+		persisted bool
+	}{
+		{name: "production", env: "production", persisted: true},
+		{name: "development", env: "development", persisted: false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, c, _ := newTestContext(t, http.MethodGet, "/test/123", "")
+
+			core, obs := observer.New(zapcore.InfoLevel)
+			sink := NewRingBufferSink(1)
+			middleware := ZapLoggerForEnvironment(ZapLoggerConfig{
+				Logger:      zap.New(core),
+				Sink:        sink,
+				Environment: tc.env,
+			})
+			handler := middleware(func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			})
+
+			require.NoError(t, handler(c))
+			assert.Len(t, obs.All(), 1)
+
+			if tc.persisted {
+				require.Eventually(t, func() bool { return len(sink.Snapshot()) == 1 }, time.Second, time.Millisecond)
+			} else {
+				time.Sleep(10 * time.Millisecond)
+				assert.Empty(t, sink.Snapshot())
+			}
+		})
+	}
+}
+
+func TestZapLoggerForEnvironmentIgnoresCollectionResolver(t *testing.T) {
+	originalInsert := mongoInsertFunc
+	t.Cleanup(func() { mongoInsertFunc = originalInsert })
+
+	var inserts int
+	mongoInsertFunc = func(ctx context.Context, collection *mongo.Collection, document interface{}) error {
+		inserts++
+		return nil
+	}
+
+	_, c, _ := newTestContext(t, http.MethodGet, "/test/123", "")
+	collection := newTestCollection(t)
+	var resolved int
+	middleware := ZapLoggerForEnvironment(ZapLoggerConfig{
+		Logger:      zap.NewNop(),
+		Environment: "staging",
+		SyncInsert:  true,
+		CollectionResolver: func(echo.Context) *mongo.Collection {
+			resolved++
+			return collection
+		},
+	})
+	handler := middleware(func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	require.NoError(t, handler(c))
+	assert.Zero(t, resolved)
+	assert.Zero(t, inserts)
+}
+
+func TestZapLoggerMaxEntryBytes(t *testing.T) {
+	tests := []struct {
+		name    string
+		max     int
+		dropped []string
+		kept    []string
+	}{
+		{name: "drops-header-and-response", max: 2500, dropped: []string{"header", "response"}, kept: []string{"body"}},
+		{name: "drops-everything", max: 500, dropped: []string{"header", "response", "body"}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, c, _ := newTestContext(t, http.MethodPost, "/test/123", strings.Repeat("b", 1000))
+			c.Request().Header.Set("X-Large", strings.Repeat("h", 2000))
+
+			core, obs := observer.New(zapcore.InfoLevel)
+			middleware := ZapLoggerWithConfig(ZapLoggerConfig{Logger: zap.New(core), MaxEntryBytes: tc.max})
+			handler := middleware(func(c echo.Context) error {
+				return c.String(http.StatusOK, strings.Repeat("r", 2000))
+			})
+
+			require.NoError(t, handler(c))
+
+			entries := obs.All()
+			require.Len(t, entries, 1)
+			fields := entries[0].ContextMap()
+			assert.Equal(t, true, fields["truncated"])
+			for _, key := range tc.dropped {
+				assert.NotContains(t, fields, key)
+			}
+			for _, key := range tc.kept {
+				assert.Contains(t, fields, key)
+			}
+		})
+	}
+}
+
+func TestZapLoggerMaxEntryBytesDropsBodyDump(t *testing.T) {
+	_, c, _ := newTestContext(t, http.MethodPost, "/test/123", strings.Repeat("b", 5000))
+
+	core, obs := observer.New(zapcore.InfoLevel)
+	middleware := ZapLoggerWithConfig(ZapLoggerConfig{
+		Logger:          zap.New(core),
+		Environment:     "development",
+		IncludeBodyDump: true,
+		MaxEntryBytes:   2000,
+	})
+	handler := middleware(func(c echo.Context) error {
+		return c.String(http.StatusOK, strings.Repeat("r", 5000))
+	})
+
+	require.NoError(t, handler(c))
+
+	entries := obs.All()
+	require.Len(t, entries, 1)
+	fields := entries[0].ContextMap()
+	assert.Equal(t, true, fields["truncated"])
+	assert.NotContains(t, fields, "body_dump")
+	assert.LessOrEqual(t, entrySize(entries[0].Context), 2000)
+}
+
+func TestZapLoggerMaxEntryBytesKeepsSmallEntries(t *testing.T) {
+	_, c, _ := newTestContext(t, http.MethodPost, "/test/123", "small")
+
+	core, obs := observer.New(zapcore.InfoLevel)
+	middleware := ZapLoggerWithConfig(ZapLoggerConfig{Logger: zap.New(core), MaxEntryBytes: 1 << 20})
+	handler := middleware(func(c echo.Context) error {
+		return c.String(http.StatusOK, "ok")
+	})
+
+	require.NoError(t, handler(c))
+
+	fields := obs.All()[0].ContextMap()
+	assert.NotContains(t, fields, "truncated")
+	assert.Equal(t, "small", fields["body"])
+}
+
+type domainError struct {
+	code string
+}
+
+func (e domainError) Error() string { return "domain failure" }
+func (e domainError) Code() string  { return e.code }
+
+func TestZapLoggerErrorCodeFunc(t *testing.T) {
+	errorCode := func(err error) (string, bool) {
+		var coded interface{ Code() string }
+		if errors.As(err, &coded) {
+			return coded.Code(), true
+		}
+		return "", false
+	}
+
+	t.Run("domain-error", func(t *testing.T) {
+		_, c, _ := newTestContext(t, http.MethodGet, "/test/123", "")
+
+		core, obs := observer.New(zapcore.InfoLevel)
+		middleware := ZapLoggerWithConfig(ZapLoggerConfig{Logger: zap.New(core), ErrorCodeFunc: errorCode})
+		handler := middleware(func(c echo.Context) error {
+			return fmt.Errorf("create order: %w", domainError{code: "ORDER_LIMIT"})
+		})
+
+		require.NoError(t, handler(c))
+		require.Len(t, obs.All(), 1)
+		assert.Equal(t, "ORDER_LIMIT", obs.All()[0].ContextMap()["error_domain_code"])
+	})
+
+	t.Run("plain-error", func(t *testing.T) {
+		_, c, _ := newTestContext(t, http.MethodGet, "/test/123", "")
+
+		core, obs := observer.New(zapcore.InfoLevel)
+		middleware := ZapLoggerWithConfig(ZapLoggerConfig{Logger: zap.New(core), ErrorCodeFunc: errorCode})
+		handler := middleware(func(c echo.Context) error {
+			return errors.New("boom")
+		})
+
+		require.NoError(t, handler(c))
+		require.Len(t, obs.All(), 1)
+		assert.NotContains(t, obs.All()[0].ContextMap(), "error_domain_code")
+	})
+}
+
+func TestZapLoggerPersistOn(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		persisted bool
+	}{
+		{name: "success-zap-only", status: http.StatusOK, persisted: false},
+		{name: "server-error-persisted", status: http.StatusInternalServerError, persisted: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, c, _ := newTestContext(t, http.MethodGet, "/test/123", "")
+
+			core, obs := observer.New(zapcore.InfoLevel)
+			sink := NewRingBufferSink(1)
+			middleware := ZapLoggerWithConfig(ZapLoggerConfig{
+				Logger:    zap.New(core),
+				Sink:      sink,
+				PersistOn: StatusClasses(4, 5),
+			})
+			handler := middleware(func(c echo.Context) error {
+				return c.NoContent(tc.status)
+			})
+
+			require.NoError(t, handler(c))
+			assert.Len(t, obs.All(), 1)
+
+			if tc.persisted {
+				require.Eventually(t, func() bool { return len(sink.Snapshot()) == 1 }, time.Second, time.Millisecond)
+			} else {
+				time.Sleep(10 * time.Millisecond)
+				assert.Empty(t, sink.Snapshot())
+			}
+		})
+	}
+}
+
+func TestZapLoggerSlowRequestWatchdog(t *testing.T) {
+	_, c, _ := newTestContext(t, http.MethodGet, "/test/123", "")
+	c.Request().Header.Set(echo.HeaderXRequestID, "slow-id")
+
+	core, obs := observer.New(zapcore.InfoLevel)
+	middleware := ZapLoggerWithConfig(ZapLoggerConfig{
+		Logger:               zap.New(core),
+		SlowRequestThreshold: 5 * time.Millisecond,
+	})
+	handler := middleware(func(c echo.Context) error {
+		require.Eventually(t, func() bool { return obs.Len() == 1 }, time.Second, time.Millisecond)
+		return c.NoContent(http.StatusOK)
+	})
+
+	require.NoError(t, handler(c))
+
+	entries := obs.All()
+	require.Len(t, entries, 2)
+	assert.Equal(t, "Request still running", entries[0].Message)
+	assert.Equal(t, zapcore.WarnLevel, entries[0].Level)
+	fields := entries[0].ContextMap()
+	assert.Equal(t, "slow-id", fields["request_id"])
+	assert.Equal(t, "00010203040506070706050403020100", fields["trace_id"])
+	assert.NotEmpty(t, fields["elapsed"])
+	assert.Equal(t, "Success", entries[1].Message)
+}
+
+func TestZapLoggerSlowRequestWatchdogStopsOnCompletion(t *testing.T) {
+	_, c, _ := newTestContext(t, http.MethodGet, "/test/123", "")
+
+	core, obs := observer.New(zapcore.InfoLevel)
+	middleware := ZapLoggerWithConfig(ZapLoggerConfig{
+		Logger:               zap.New(core),
+		SlowRequestThreshold: 20 * time.Millisecond,
+	})
+	handler := middleware(func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	require.NoError(t, handler(c))
+	time.Sleep(40 * time.Millisecond)
+	assert.Equal(t, 1, obs.Len())
+}
+
+func TestZapLoggerSlowRequestWatchdogStopsOnPanic(t *testing.T) {
+	_, c, _ := newTestContext(t, http.MethodGet, "/test/123", "")
+
+	core, obs := observer.New(zapcore.InfoLevel)
+	zapLogger := ZapLoggerWithConfig(ZapLoggerConfig{
+		Logger:               zap.New(core),
+		SlowRequestThreshold: 20 * time.Millisecond,
+	})
+	recoverer := middleware.RecoverWithConfig(middleware.RecoverConfig{DisablePrintStack: true})
+	handler := recoverer(zapLogger(func(c echo.Context) error {
+		panic("boom")
+	}))
+
+	require.NoError(t, handler(c))
+	time.Sleep(50 * time.Millisecond)
+	assert.Empty(t, obs.FilterMessage("Request still running").All())
+}
+
+func TestZapLoggerEnsureResponseRequestID(t *testing.T) {
+	t.Run("observed", func(t *testing.T) {
+		_, c, rec := newTestContext(t, http.MethodGet, "/test/123", "")
+		c.Request().Header.Set(echo.HeaderXRequestID, "upstream-id")
+
+		middleware := ZapLoggerWithConfig(ZapLoggerConfig{Logger: zap.NewNop(), EnsureResponseRequestID: true})
+		handler := middleware(func(c echo.Context) error {
+			return c.String(http.StatusOK, "ok")
+		})
+
+		require.NoError(t, handler(c))
+		assert.Equal(t, "upstream-id", rec.Header().Get(echo.HeaderXRequestID))
+	})
+
+	t.Run("generated", func(t *testing.T) {
+		_, c, rec := newTestContext(t, http.MethodGet, "/test/123", "")
+
+		core, obs := observer.New(zapcore.InfoLevel)
+		middleware := ZapLoggerWithConfig(ZapLoggerConfig{
+			Logger:                  zap.New(core),
+			EnsureResponseRequestID: true,
+			RequestIDGenerator:      func() string { return "generated-id" },
+		})
+		handler := middleware(func(c echo.Context) error {
+			return c.String(http.StatusOK, "ok")
+		})
+
+		require.NoError(t, handler(c))
+		assert.Equal(t, "generated-id", rec.Header().Get(echo.HeaderXRequestID))
+		require.Len(t, obs.All(), 1)
+		assert.Equal(t, "generated-id", obs.All()[0].ContextMap()["request_id"])
+	})
+
+	t.Run("default-generator", func(t *testing.T) {
+		_, c, rec := newTestContext(t, http.MethodGet, "/test/123", "")
+
+		middleware := ZapLoggerWithConfig(ZapLoggerConfig{Logger: zap.NewNop(), EnsureResponseRequestID: true})
+		handler := middleware(func(c echo.Context) error {
+			return c.NoContent(http.StatusOK)
+		})
+
+		require.NoError(t, handler(c))
+		assert.Len(t, rec.Header().Get(echo.HeaderXRequestID), 32)
+	})
+}
+
+// BenchmarkHeaderField builds the header field for a typical request and logs it to a core that
+// rejects the entry, as a sampled or leveled-out logger does.
+//
+// go1.27 linux/amd64, 6 headers:
+//
+//	eager fmt.Sprintf:  ~5740 ns/op  928 B/op  21 allocs/op
+//	lazy zap.Stringer:   ~100 ns/op   64 B/op   1 allocs/op
+//
+// The lazy field defers formatting to the encoder, so dropped entries never render the header.
+func BenchmarkHeaderField(b *testing.B) {
+	header := http.Header{
+		"Accept":          {"application/json"},
+		"Accept-Encoding": {"gzip, deflate"},
+		"Content-Type":    {"application/json"},
+		"User-Agent":      {"unit-agent/1.0"},
+		"X-Request-Id":    {"0123456789abcdef0123456789abcdef"},
+		"Traceparent":     {"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+	}
+	logger := zap.New(zapcore.NewNopCore())
+
+	b.Run("eager", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			logger.Info("Success", zap.String("header", fmt.Sprintf("%v", header)))
+		}
+	})
+	b.Run("lazy", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			logger.Info("Success", zap.Stringer("header", headerStringer(header)))
+		}
+	})
+}
+
+// BenchmarkZapFieldsToMap converts a typical access log field set.
+//
+// go1.27 linux/amd64, 22 fields:
+//
+//	range loop (current):       ~1260 ns/op  1520 B/op  22 allocs/op
+//	indexed loop, *Field access: ~1330 ns/op  1520 B/op  22 allocs/op
+//
+// The map is already pre-sized and the switch does no reflection; the remaining allocations are
+// the map itself and boxing each value into interface{}. A sync.Pool cannot help because sinks
+// retain the document after Insert returns, so the current implementation is kept.
+func BenchmarkZapFieldsToMap(b *testing.B) {
+	fields := []zapcore.Field{
+		zap.Int("status", http.StatusOK),
+		zap.String("latency", "1.2ms"),
+		zap.String("request_id", "req-id"),
+		zap.String("trace_id", "0102030405060708090a0b0c0d0e0f10"),
+		zap.String("span_id", "0102030405060708"),
+		zap.String("time", "2025-01-01T00:00:00Z"),
+		zap.Int64("timestamp", 1735689600),
+		zap.String("method", http.MethodPost),
+		zap.String("uri", "/test/123?foo=bar"),
+		zap.String("host", "example.local"),
+		zap.String("remote_ip", "10.0.0.1"),
+		zap.String("header", "map[Content-Type:[application/json]]"),
+		zap.String("path", "/test/:id"),
+		zap.String("query", "foo=bar"),
+		zap.String("form", ""),
+		zap.String("param", "[123]"),
+		zap.Any("params", map[string]string{"id": "123"}),
+		zap.String("body", `{"foo":"bar"}`),
+		zap.String("user_agent", "unit-agent"),
+		zap.String("referer", ""),
+		zap.String("request_proto", "HTTP/1.1"),
+		zap.String("response", `{"ok":true}`),
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = zapFieldsToMap(fields)
+	}
+}
+
+func TestZapLoggerLogBodyContentTypes(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+	}{
+		{name: "json-logged", contentType: echo.MIMEApplicationJSONCharsetUTF8, body: `{"a":1}`},
+		{name: "form-logged", contentType: echo.MIMEApplicationForm, body: "a=1"},
+		{name: "octet-stream-skipped", contentType: echo.MIMEOctetStream, body: ""},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			payload := tc.body
+			if payload == "" {
+				payload = "binary-payload"
+			}
+			_, c, _ := newTestContext(t, http.MethodPost, "/test/123", payload)
+			c.Request().Header.Set(echo.HeaderContentType, tc.contentType)
+
+			core, obs := observer.New(zapcore.InfoLevel)
+			middleware := ZapLoggerWithConfig(ZapLoggerConfig{
+				Logger:              zap.New(core),
+				LogBodyContentTypes: []string{echo.MIMEApplicationJSON, echo.MIMEApplicationForm},
+			})
+			handler := middleware(func(c echo.Context) error {
+				body, err := io.ReadAll(c.Request().Body)
+				require.NoError(t, err)
+				assert.Equal(t, payload, string(body))
+				return c.NoContent(http.StatusOK)
+			})
+
+			require.NoError(t, handler(c))
+			require.Len(t, obs.All(), 1)
+			assert.Equal(t, tc.body, obs.All()[0].ContextMap()["body"])
+		})
+	}
+}
+
+func TestZapLoggerSessionCookieSummary(t *testing.T) {
+	tests := []struct {
+		name       string
+		cookies    []*http.Cookie
+		hasSession bool
+	}{
+		{name: "with-session", cookies: []*http.Cookie{{Name: "sid", Value: "secret-session"}, {Name: "theme", Value: "dark"}}, hasSession: true},
+		{name: "anonymous", cookies: []*http.Cookie{{Name: "theme", Value: "dark"}}, hasSession: false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, c, _ := newTestContext(t, http.MethodGet, "/test/123", "")
+			for _, cookie := range tc.cookies {
+				c.Request().AddCookie(cookie)
+			}
+
+			core, obs := observer.New(zapcore.InfoLevel)
+			middleware := ZapLoggerWithConfig(ZapLoggerConfig{
+				Logger:            zap.New(core),
+				SessionCookieName: "sid",
+			})
+			handler := middleware(func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			})
+
+			require.NoError(t, handler(c))
+			require.Len(t, obs.All(), 1)
+			fields := obs.All()[0].ContextMap()
+			assert.Equal(t, tc.hasSession, fields["has_session"])
+			assert.Equal(t, int64(len(tc.cookies)), fields["cookie_count"])
+		})
+	}
+}
+
+func TestZapLoggerLogsW3CTraceContextIDs(t *testing.T) {
+	_, c, _ := newTestContext(t, http.MethodGet, "/test/123", "")
+
+	core, obs := observer.New(zapcore.InfoLevel)
+	handler := ZapLogger(zap.New(core), nil)(func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	require.NoError(t, handler(c))
+	require.Len(t, obs.All(), 1)
+
+	fields := obs.All()[0].ContextMap()
+	assert.Regexp(t, `^[0-9a-f]{32}$`, fields["trace_id"])
+	assert.Regexp(t, `^[0-9a-f]{16}$`, fields["span_id"])
+	assert.Equal(t, "00010203040506070706050403020100", fields["trace_id"])
+	assert.Equal(t, "0807060504030201", fields["span_id"])
+}
+
+func TestZapLoggerTraceContextIDsWithoutSpan(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/plain", nil)
+	c := e.NewContext(req, httptest.NewRecorder())
+
+	core, obs := observer.New(zapcore.InfoLevel)
+	handler := ZapLogger(zap.New(core), nil)(func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	require.NoError(t, handler(c))
+	require.Len(t, obs.All(), 1)
+
+	fields := obs.All()[0].ContextMap()
+	assert.Empty(t, fields["trace_id"])
+	assert.Empty(t, fields["span_id"])
+}
+
+func TestZapLoggerFullURLRedactsQueryParams(t *testing.T) {
+	_, c, _ := newTestContext(t, http.MethodGet, "/test/123?token=s3cret&page=2&q=a+b", "")
+
+	core, obs := observer.New(zapcore.InfoLevel)
+	middleware := ZapLoggerWithConfig(ZapLoggerConfig{
+		Logger:            zap.New(core),
+		RedactQueryParams: []string{"token"},
+		LogFullURL:        true,
+	})
+	handler := middleware(func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	require.NoError(t, handler(c))
+	require.Len(t, obs.All(), 1)
+
+	fields := obs.All()[0].ContextMap()
+	assert.Equal(t, "/test/123?page=2&q=a+b&token=***", fields["full_url"])
+	assert.Equal(t, "page=2&q=a+b&token=***", fields["query"])
+	assert.NotContains(t, fields["full_url"], "s3cret")
+}
+
+func TestZapLoggerRedactQueryParamsInEveryField(t *testing.T) {
+	_, c, _ := newTestContext(t, http.MethodGet, "/test/123?token=s3cret&page=2", "")
+	c.Request().Form = nil
+
+	core, obs := observer.New(zapcore.InfoLevel)
+	middleware := ZapLoggerWithConfig(ZapLoggerConfig{
+		Logger:               zap.New(core),
+		RedactQueryParams:    []string{"token"},
+		LogFullURL:           true,
+		SlowRequestThreshold: 5 * time.Millisecond,
+	})
+	handler := middleware(func(c echo.Context) error {
+		require.Eventually(t, func() bool { return obs.Len() == 1 }, time.Second, time.Millisecond)
+		assert.Equal(t, "2", c.FormValue("page"))
+		return c.NoContent(http.StatusOK)
+	})
+
+	require.NoError(t, handler(c))
+	entries := obs.All()
+	require.Len(t, entries, 2)
+	assert.Equal(t, "/test/123?page=2&token=***", entries[0].ContextMap()["uri"])
+	assert.Equal(t, "/test/123?page=2&token=***", entries[1].ContextMap()["uri"])
+	assert.Equal(t, "page=2&token=***", entries[1].ContextMap()["form"])
+	for _, entry := range entries {
+		for key, value := range entry.ContextMap() {
+			assert.NotContains(t, fmt.Sprint(value), "s3cret", "field %s of %q", key, entry.Message)
+		}
+	}
+}
+
+func TestZapLoggerFullURLWithoutQuery(t *testing.T) {
+	_, c, _ := newTestContext(t, http.MethodGet, "/test/123", "")
+
+	core, obs := observer.New(zapcore.InfoLevel)
+	middleware := ZapLoggerWithConfig(ZapLoggerConfig{Logger: zap.New(core), LogFullURL: true})
+	handler := middleware(func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	require.NoError(t, handler(c))
+	assert.Equal(t, "/test/123", obs.All()[0].ContextMap()["full_url"])
+}
+
+func TestZapLoggerCustomResponseCapture(t *testing.T) {
+	_, c, rec := newTestContext(t, http.MethodGet, "/test/123", "")
+
+	core, obs := observer.New(zapcore.InfoLevel)
+	middleware := ZapLoggerWithConfig(ZapLoggerConfig{
+		Logger: zap.New(core),
+		ResponseCapture: func(c echo.Context) (io.Writer, func() string) {
+			hash := sha256.New()
+			return hash, func() string { return "sha256:" + hex.EncodeToString(hash.Sum(nil)) }
+		},
+	})
+	handler := middleware(func(c echo.Context) error {
+		return c.String(http.StatusOK, "streamed payload")
+	})
+
+	require.NoError(t, handler(c))
+	assert.Equal(t, "streamed payload", rec.Body.String())
+
+	sum := sha256.Sum256([]byte("streamed payload"))
+	require.Len(t, obs.All(), 1)
+	assert.Equal(t, "sha256:"+hex.EncodeToString(sum[:]), obs.All()[0].ContextMap()["response"])
 }
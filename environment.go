@@ -0,0 +1,18 @@
+package echomiddleware
+
+import "github.com/spf13/viper"
+
+// productionEnvironment is the ENVIRONMENT value that disables development-only logging
+const productionEnvironment = "production"
+
+// environment returns override when set, otherwise the ENVIRONMENT value read through viper
+func environment(override string) string {
+	if override != "" {
+		return override
+	}
+	return viper.GetString("ENVIRONMENT")
+}
+
+func isProduction(override string) bool {
+	return environment(override) == productionEnvironment
+}
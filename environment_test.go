@@ -0,0 +1,20 @@
+package echomiddleware
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnvironmentOverridesViper(t *testing.T) {
+	viper.Set("ENVIRONMENT", "production")
+	t.Cleanup(func() {
+		viper.Set("ENVIRONMENT", "")
+	})
+
+	assert.Equal(t, "production", environment(""))
+	assert.True(t, isProduction(""))
+	assert.Equal(t, "development", environment("development"))
+	assert.False(t, isProduction("development"))
+}
@@ -0,0 +1,62 @@
+package echomiddleware
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// FieldBag adds strongly typed fields to the access log emitted by ZapLogger for a request.
+// Setting the same key twice keeps the latest value, and keys are shared with AddLogField.
+// A FieldBag obtained outside ZapLogger discards every field.
+type FieldBag struct {
+	state *logState
+}
+
+// NewFieldBag returns the field bag of the request carried by ctx
+func NewFieldBag(ctx context.Context) *FieldBag {
+	return &FieldBag{state: logStateFromContext(ctx)}
+}
+
+func (b *FieldBag) set(field zapcore.Field) {
+	if b.state != nil {
+		b.state.set(field)
+	}
+}
+
+// SetString sets a string field
+func (b *FieldBag) SetString(key, value string) {
+	b.set(zap.String(key, value))
+}
+
+// SetInt sets an integer field
+func (b *FieldBag) SetInt(key string, value int) {
+	b.set(zap.Int(key, value))
+}
+
+// SetInt64 sets a 64-bit integer field
+func (b *FieldBag) SetInt64(key string, value int64) {
+	b.set(zap.Int64(key, value))
+}
+
+// SetFloat64 sets a floating point field
+func (b *FieldBag) SetFloat64(key string, value float64) {
+	b.set(zap.Float64(key, value))
+}
+
+// SetBool sets a boolean field
+func (b *FieldBag) SetBool(key string, value bool) {
+	b.set(zap.Bool(key, value))
+}
+
+// SetDuration sets a duration field
+func (b *FieldBag) SetDuration(key string, value time.Duration) {
+	b.set(zap.Duration(key, value))
+}
+
+// SetTime sets a time field
+func (b *FieldBag) SetTime(key string, value time.Time) {
+	b.set(zap.Time(key, value))
+}
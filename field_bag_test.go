@@ -0,0 +1,45 @@
+package echomiddleware
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestFieldBagAppearsInAccessLog(t *testing.T) {
+	_, c, _ := newTestContext(t, http.MethodPost, "/test/123", "")
+
+	core, obs := observer.New(zapcore.InfoLevel)
+	sink := NewRingBufferSink(1)
+	middleware := ZapLoggerWithConfig(ZapLoggerConfig{Logger: zap.New(core), Sink: sink, SyncInsert: true})
+	handler := middleware(func(c echo.Context) error {
+		bag := NewFieldBag(c.Request().Context())
+		bag.SetString("order_id", "ord-42")
+		bag.SetInt("items", 3)
+		bag.SetInt("items", 4)
+		return c.NoContent(http.StatusOK)
+	})
+
+	require.NoError(t, handler(c))
+
+	require.Len(t, obs.All(), 1)
+	fields := obs.All()[0].ContextMap()
+	assert.Equal(t, "ord-42", fields["order_id"])
+	assert.Equal(t, int64(4), fields["items"])
+	assert.Equal(t, int64(4), sink.Snapshot()[0]["items"])
+}
+
+func TestFieldBagWithoutZapLoggerIsNoop(t *testing.T) {
+	bag := NewFieldBag(context.Background())
+	assert.NotPanics(t, func() {
+		bag.SetString("order_id", "ord-42")
+		bag.SetBool("retried", true)
+	})
+}
@@ -0,0 +1,24 @@
+package echomiddleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+)
+
+// requestFingerprint returns the hex SHA-256 of the method, URL path, query, and body SHA-256, joined by
+// newlines. The query is encoded with keys sorted and values in request order, so the fingerprint only
+// depends on the request itself and is stable across processes and runs.
+func requestFingerprint(req *http.Request, body []byte) string {
+	bodySum := sha256.Sum256(body)
+
+	hash := sha256.New()
+	hash.Write([]byte(req.Method))
+	hash.Write([]byte("\n"))
+	hash.Write([]byte(req.URL.Path))
+	hash.Write([]byte("\n"))
+	hash.Write([]byte(req.URL.Query().Encode()))
+	hash.Write([]byte("\n"))
+	hash.Write([]byte(hex.EncodeToString(bodySum[:])))
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,39 @@
+package echomiddleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestZapLoggerFingerprintIsStable(t *testing.T) {
+	core, obs := observer.New(zapcore.InfoLevel)
+	middleware := ZapLoggerWithConfig(ZapLoggerConfig{Logger: zap.New(core), LogFingerprint: true})
+	handler := middleware(func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	for _, target := range []string{"/test/123?b=2&a=1", "/test/123?a=1&b=2", "/test/123?a=1&b=3"} {
+		_, c, _ := newTestContext(t, http.MethodPost, target, `{"id":1}`)
+		require.NoError(t, handler(c))
+	}
+
+	entries := obs.All()
+	require.Len(t, entries, 3)
+	first := entries[0].ContextMap()["fingerprint"]
+	assert.Len(t, first, 64)
+	assert.Equal(t, first, entries[1].ContextMap()["fingerprint"])
+	assert.NotEqual(t, first, entries[2].ContextMap()["fingerprint"])
+}
+
+func TestRequestFingerprintKnownValue(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/orders?id=7", nil)
+	assert.Equal(t, "07252001ed10a406678bbe183ed7ad39783daa9b60d950bcec11ef61bb1613c9", requestFingerprint(req, nil))
+}
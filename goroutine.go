@@ -0,0 +1,29 @@
+package echomiddleware
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+)
+
+// Go runs fn in a new goroutine with ctx, so the logger and the trace_id, span_id, and request_id
+// stored by LoggerWithContext are available through the context getters inside fn.
+// A panic in fn is recovered and logged with the context logger.
+// The returned channel receives fn's error, or the recovered panic as an error, and is then closed.
+func Go(ctx context.Context, fn func(ctx context.Context) error) <-chan error {
+	done := make(chan error, 1)
+	go func() {
+		defer close(done)
+		defer func() {
+			if r := recover(); r != nil {
+				GetLoggerFromContext(ctx).Errorw("Recovered from panic in goroutine",
+					"panic", fmt.Sprint(r),
+					"stack", string(debug.Stack()),
+				)
+				done <- fmt.Errorf("panic: %v", r)
+			}
+		}()
+		done <- fn(ctx)
+	}()
+	return done
+}
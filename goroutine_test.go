@@ -0,0 +1,64 @@
+package echomiddleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestGoCarriesCorrelationContext(t *testing.T) {
+	core, obs := observer.New(zapcore.InfoLevel)
+	undo := zap.ReplaceGlobals(zap.New(core))
+	t.Cleanup(func() { undo() })
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/fan-out", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	spanCtx := testSpanContext()
+	c.SetRequest(req.WithContext(trace.ContextWithSpanContext(context.Background(), spanCtx)))
+
+	handler := LoggerWithContext()(func(c echo.Context) error {
+		first := Go(c.Request().Context(), func(ctx context.Context) error {
+			GetLoggerFromContext(ctx).Info("fan-out work")
+			return nil
+		})
+		second := Go(c.Request().Context(), func(ctx context.Context) error {
+			return errors.New("work failed")
+		})
+		assert.NoError(t, <-first)
+		assert.EqualError(t, <-second, "work failed")
+		return nil
+	})
+
+	require.NoError(t, handler(c))
+
+	entries := obs.FilterMessage("fan-out work").All()
+	require.Len(t, entries, 1)
+	assert.Equal(t, spanCtx.TraceID().String(), entries[0].ContextMap()["trace_id"])
+}
+
+func TestGoRecoversPanic(t *testing.T) {
+	core, obs := observer.New(zapcore.InfoLevel)
+	undo := zap.ReplaceGlobals(zap.New(core))
+	t.Cleanup(func() { undo() })
+
+	err := <-Go(context.Background(), func(ctx context.Context) error {
+		panic("boom")
+	})
+
+	require.EqualError(t, err, "panic: boom")
+	entries := obs.FilterMessage("Recovered from panic in goroutine").All()
+	require.Len(t, entries, 1)
+	assert.Equal(t, "boom", entries[0].ContextMap()["panic"])
+}
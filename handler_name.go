@@ -0,0 +1,24 @@
+package echomiddleware
+
+import (
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// handlerFields returns the name of the handler selected by the router.
+// Echo wraps every route handler in a closure, so c.Handler() cannot be resolved with runtime.FuncForPC.
+// The name is taken from the matching route instead, which Echo resolves with runtime.FuncForPC when
+// the route is registered unless it was renamed.
+func handlerFields(c echo.Context) []zapcore.Field {
+	if c.Echo() == nil {
+		return nil
+	}
+	method, path := c.Request().Method, c.Path()
+	for _, route := range c.Echo().Routes() {
+		if route.Method == method && route.Path == path {
+			return []zapcore.Field{zap.String("handler", route.Name)}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package echomiddleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func getOrderHandler(c echo.Context) error {
+	return c.NoContent(http.StatusOK)
+}
+
+func TestZapLoggerLogsHandlerName(t *testing.T) {
+	tests := []struct {
+		name        string
+		environment string
+		logged      bool
+	}{
+		{name: "development", environment: "development", logged: true},
+		{name: "production", environment: "production"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			core, obs := observer.New(zapcore.InfoLevel)
+
+			e := echo.New()
+			e.Use(ZapLoggerWithConfig(ZapLoggerConfig{
+				Logger:         zap.New(core),
+				Environment:    tc.environment,
+				LogHandlerName: true,
+			}))
+			e.GET("/orders/:id", getOrderHandler)
+			e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/orders/7", nil))
+
+			require.Len(t, obs.All(), 1)
+			fields := obs.All()[0].ContextMap()
+			if !tc.logged {
+				assert.NotContains(t, fields, "handler")
+				return
+			}
+			assert.Equal(t, "github.com/goffity/echo-middleware.getOrderHandler", fields["handler"])
+		})
+	}
+}
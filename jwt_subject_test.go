@@ -0,0 +1,77 @@
+package echomiddleware
+
+import (
+	"encoding/base64"
+	"net/http"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func testJWT(payload string) string {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+	return header + "." + base64.RawURLEncoding.EncodeToString([]byte(payload)) + ".signature"
+}
+
+func TestJWTSubject(t *testing.T) {
+	sub, ok := jwtSubject("Bearer " + testJWT(`{"sub":"user-42","name":"Jane"}`))
+	require.True(t, ok)
+	assert.Equal(t, "user-42", sub)
+
+	for _, header := range []string{
+		"",
+		"Basic dXNlcjpwYXNz",
+		"Bearer not-a-jwt",
+		"Bearer a.!!!.c",
+		"Bearer " + testJWT(`not json`),
+		"Bearer " + testJWT(`{"name":"no subject"}`),
+	} {
+		_, ok := jwtSubject(header)
+		assert.False(t, ok, header)
+	}
+}
+
+func TestZapLoggerLogJWTSubject(t *testing.T) {
+	tests := []struct {
+		name          string
+		environment   string
+		authorization string
+		expected      string
+	}{
+		{name: "valid-token", environment: "development", authorization: "Bearer " + testJWT(`{"sub":"user-42"}`), expected: "user-42"},
+		{name: "malformed-token", environment: "development", authorization: "Bearer garbage"},
+		{name: "production", environment: "production", authorization: "Bearer " + testJWT(`{"sub":"user-42"}`)},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, c, rec := newTestContext(t, http.MethodGet, "/test/123", "")
+			c.Request().Header.Set(echo.HeaderAuthorization, tc.authorization)
+
+			core, obs := observer.New(zapcore.InfoLevel)
+			middleware := ZapLoggerWithConfig(ZapLoggerConfig{
+				Logger:        zap.New(core),
+				Environment:   tc.environment,
+				LogJWTSubject: true,
+			})
+			handler := middleware(func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			})
+
+			require.NoError(t, handler(c))
+			assert.Equal(t, http.StatusOK, rec.Code)
+			require.Len(t, obs.All(), 1)
+
+			fields := obs.All()[0].ContextMap()
+			if tc.expected == "" {
+				assert.NotContains(t, fields, "jwt_sub")
+			} else {
+				assert.Equal(t, tc.expected, fields["jwt_sub"])
+			}
+		})
+	}
+}
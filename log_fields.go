@@ -0,0 +1,141 @@
+package echomiddleware
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// logFieldsContextKey stores the per-request log state installed by ZapLogger
+const logFieldsContextKey = "log_fields"
+
+// logState collects fields added by handlers, services, and repositories while a request is in flight.
+// ZapLogger installs one per request and merges its fields into the completion log.
+type logState struct {
+	mu         sync.Mutex
+	fields     []zapcore.Field
+	operations operationStats
+	db         dbStats
+}
+
+// set adds the field, replacing any field previously added under the same key
+func (s *logState) set(field zapcore.Field) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.fields {
+		if s.fields[i].Key == field.Key {
+			s.fields[i] = field
+			return
+		}
+	}
+	s.fields = append(s.fields, field)
+}
+
+// snapshot returns a copy of the collected fields followed by the recorded operation and query summaries
+func (s *logState) snapshot() []zapcore.Field {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fields := append([]zapcore.Field(nil), s.fields...)
+	fields = append(fields, s.operations.fields()...)
+	return append(fields, s.db.fields()...)
+}
+
+func withLogState(ctx context.Context) (context.Context, *logState) {
+	state := &logState{}
+	return context.WithValue(ctx, logFieldsContextKey, state), state
+}
+
+func logStateFromContext(ctx context.Context) *logState {
+	if state, ok := ctx.Value(logFieldsContextKey).(*logState); ok {
+		return state
+	}
+	return nil
+}
+
+// AddLogField adds a field to the access log emitted by ZapLogger for the current request.
+// Adding the same key twice keeps the latest value. It is a no-op when ZapLogger is not mounted.
+func AddLogField(c echo.Context, key string, value interface{}) {
+	AddLogFieldToContext(c.Request().Context(), key, value)
+}
+
+// AddLogFieldToContext adds a field to the access log from standard Go context
+// Use this in service and repository layers
+func AddLogFieldToContext(ctx context.Context, key string, value interface{}) {
+	if state := logStateFromContext(ctx); state != nil {
+		state.set(zap.Any(key, value))
+	}
+}
+
+// AddTraceAttribute sets the attribute on the active span and adds it to the access log emitted by
+// ZapLogger, so traces and logs carry the same value. Either side is skipped when absent.
+func AddTraceAttribute(ctx context.Context, key string, value interface{}) {
+	trace.SpanFromContext(ctx).SetAttributes(traceAttribute(key, value))
+	AddLogFieldToContext(ctx, key, value)
+}
+
+// traceAttribute converts value to a span attribute, formatting unsupported types as strings
+func traceAttribute(key string, value interface{}) attribute.KeyValue {
+	switch v := value.(type) {
+	case string:
+		return attribute.String(key, v)
+	case bool:
+		return attribute.Bool(key, v)
+	case int:
+		return attribute.Int(key, v)
+	case int64:
+		return attribute.Int64(key, v)
+	case float64:
+		return attribute.Float64(key, v)
+	case []string:
+		return attribute.StringSlice(key, v)
+	default:
+		return attribute.String(key, fmt.Sprint(v))
+	}
+}
+
+// CacheResult is the outcome of a cache lookup logged as cache
+type CacheResult string
+
+// Cache lookup outcomes
+const (
+	CacheHit    CacheResult = "hit"
+	CacheMiss   CacheResult = "miss"
+	CacheBypass CacheResult = "bypass"
+)
+
+// SetCacheResult records the cache outcome of the current request, logged by ZapLogger as cache.
+// The latest call wins. It is a no-op when ZapLogger is not mounted.
+func SetCacheResult(ctx context.Context, result CacheResult) {
+	if state := logStateFromContext(ctx); state != nil {
+		state.set(zap.String("cache", string(result)))
+	}
+}
+
+// SetAuthDecision records the authorization outcome of the current request, logged by ZapLogger as
+// auth_scope and auth_decision (allow or deny). It is a no-op when ZapLogger is not mounted.
+func SetAuthDecision(ctx context.Context, scope string, allowed bool) {
+	state := logStateFromContext(ctx)
+	if state == nil {
+		return
+	}
+	decision := "deny"
+	if allowed {
+		decision = "allow"
+	}
+	state.set(zap.String("auth_scope", scope))
+	state.set(zap.String("auth_decision", decision))
+}
+
+// SetUpstream records the backend a gateway routed the current request to, logged by ZapLogger as upstream.
+// The latest call wins, e.g. after a retry against another backend. It is a no-op when ZapLogger is not mounted.
+func SetUpstream(ctx context.Context, name string) {
+	if state := logStateFromContext(ctx); state != nil {
+		state.set(zap.String("upstream", name))
+	}
+}
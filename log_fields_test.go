@@ -0,0 +1,172 @@
+package echomiddleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestAddLogFieldAppearsInAccessLog(t *testing.T) {
+	_, c, _ := newTestContext(t, http.MethodPost, "/test/123", "body")
+
+	core, obs := observer.New(zapcore.InfoLevel)
+	logger := zap.New(core)
+
+	middleware := ZapLogger(logger, nil)
+	handler := middleware(func(c echo.Context) error {
+		AddLogField(c, "order_id", "ord-42")
+		AddLogFieldToContext(c.Request().Context(), "items", 3)
+		AddLogField(c, "order_id", "ord-43")
+		return c.NoContent(http.StatusOK)
+	})
+
+	require.NoError(t, handler(c))
+
+	entries := obs.All()
+	require.Len(t, entries, 1)
+	fields := entries[0].ContextMap()
+	assert.Equal(t, "ord-43", fields["order_id"])
+	assert.Equal(t, int64(3), fields["items"])
+}
+
+func TestAddLogFieldPersistsErrorValues(t *testing.T) {
+	_, c, _ := newTestContext(t, http.MethodGet, "/test/123", "")
+
+	sink := &recordingSink{}
+	middleware := ZapLoggerWithConfig(ZapLoggerConfig{Logger: zap.NewNop(), Sink: sink, SyncInsert: true})
+	handler := middleware(func(c echo.Context) error {
+		AddLogField(c, "cause", errors.New("upstream timeout"))
+		AddLogField(c, "payload", []byte("raw"))
+		return c.NoContent(http.StatusOK)
+	})
+
+	require.NoError(t, handler(c))
+	require.Len(t, sink.documents, 1)
+	assert.Equal(t, "upstream timeout", sink.documents[0]["cause"])
+	assert.Equal(t, []byte("raw"), sink.documents[0]["payload"])
+}
+
+func TestAddLogFieldWithoutZapLoggerIsNoop(t *testing.T) {
+	_, c, _ := newTestContext(t, http.MethodGet, "/test/123", "")
+
+	assert.NotPanics(t, func() {
+		AddLogField(c, "order_id", "ord-42")
+		AddLogFieldToContext(context.Background(), "order_id", "ord-42")
+	})
+}
+
+// attributeRecordingSpan is a valid span that records the attributes set on it
+type attributeRecordingSpan struct {
+	noop.Span
+	attributes []attribute.KeyValue
+}
+
+func (s *attributeRecordingSpan) SpanContext() trace.SpanContext { return testSpanContext() }
+
+func (s *attributeRecordingSpan) SetAttributes(kv ...attribute.KeyValue) {
+	s.attributes = append(s.attributes, kv...)
+}
+
+func TestAddTraceAttribute(t *testing.T) {
+	_, c, _ := newTestContext(t, http.MethodGet, "/test/123", "")
+	span := &attributeRecordingSpan{}
+	c.SetRequest(c.Request().WithContext(trace.ContextWithSpan(c.Request().Context(), span)))
+
+	core, obs := observer.New(zapcore.InfoLevel)
+	middleware := ZapLogger(zap.New(core), nil)
+	handler := middleware(func(c echo.Context) error {
+		AddTraceAttribute(c.Request().Context(), "tenant_id", "acme")
+		return c.NoContent(http.StatusOK)
+	})
+
+	require.NoError(t, handler(c))
+
+	assert.Contains(t, span.attributes, attribute.String("tenant_id", "acme"))
+	require.Len(t, obs.All(), 1)
+	assert.Equal(t, "acme", obs.All()[0].ContextMap()["tenant_id"])
+}
+
+func TestAddTraceAttributeSliceMatchesPersistedLog(t *testing.T) {
+	_, c, _ := newTestContext(t, http.MethodGet, "/test/123", "")
+	span := &attributeRecordingSpan{}
+	c.SetRequest(c.Request().WithContext(trace.ContextWithSpan(c.Request().Context(), span)))
+
+	sink := &recordingSink{}
+	middleware := ZapLoggerWithConfig(ZapLoggerConfig{Logger: zap.NewNop(), Sink: sink, SyncInsert: true})
+	handler := middleware(func(c echo.Context) error {
+		AddTraceAttribute(c.Request().Context(), "feature_flags", []string{"beta", "dark_mode"})
+		return c.NoContent(http.StatusOK)
+	})
+
+	require.NoError(t, handler(c))
+
+	assert.Contains(t, span.attributes, attribute.StringSlice("feature_flags", []string{"beta", "dark_mode"}))
+	require.Len(t, sink.documents, 1)
+	assert.Equal(t, []interface{}{"beta", "dark_mode"}, sink.documents[0]["feature_flags"])
+}
+
+func TestSetCacheResult(t *testing.T) {
+	for _, result := range []CacheResult{CacheHit, CacheMiss, CacheBypass} {
+		t.Run(string(result), func(t *testing.T) {
+			_, c, _ := newTestContext(t, http.MethodGet, "/test/123", "")
+
+			core, obs := observer.New(zapcore.InfoLevel)
+			middleware := ZapLogger(zap.New(core), nil)
+			handler := middleware(func(c echo.Context) error {
+				SetCacheResult(c.Request().Context(), result)
+				return c.NoContent(http.StatusOK)
+			})
+
+			require.NoError(t, handler(c))
+
+			require.Len(t, obs.All(), 1)
+			assert.Equal(t, string(result), obs.All()[0].ContextMap()["cache"])
+		})
+	}
+}
+
+func TestSetAuthDecisionDenied(t *testing.T) {
+	_, c, _ := newTestContext(t, http.MethodDelete, "/test/123", "")
+
+	core, obs := observer.New(zapcore.InfoLevel)
+	middleware := ZapLogger(zap.New(core), nil)
+	handler := middleware(func(c echo.Context) error {
+		SetAuthDecision(c.Request().Context(), "orders:delete", false)
+		return c.NoContent(http.StatusForbidden)
+	})
+
+	require.NoError(t, handler(c))
+
+	require.Len(t, obs.All(), 1)
+	fields := obs.All()[0].ContextMap()
+	assert.Equal(t, "orders:delete", fields["auth_scope"])
+	assert.Equal(t, "deny", fields["auth_decision"])
+}
+
+func TestSetUpstream(t *testing.T) {
+	_, c, _ := newTestContext(t, http.MethodGet, "/test/123", "")
+
+	core, obs := observer.New(zapcore.InfoLevel)
+	middleware := ZapLogger(zap.New(core), nil)
+	handler := middleware(func(c echo.Context) error {
+		SetUpstream(c.Request().Context(), "orders-primary")
+		SetUpstream(c.Request().Context(), "orders-replica")
+		return c.NoContent(http.StatusBadGateway)
+	})
+
+	require.NoError(t, handler(c))
+
+	require.Len(t, obs.All(), 1)
+	assert.Equal(t, "orders-replica", obs.All()[0].ContextMap()["upstream"])
+}
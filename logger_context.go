@@ -2,9 +2,16 @@ package echomiddleware
 
 import (
 	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
 
 	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
@@ -20,35 +27,149 @@ const (
 	RequestIDAttribute = "request.id"
 )
 
+var (
+	requestIDHeaderMu sync.RWMutex
+	requestIDHeader   = echo.HeaderXRequestID
+)
+
+// SetRequestIDHeader sets the header the middlewares read the request ID from, e.g. X-Correlation-ID
+// Defaults to X-Request-ID
+func SetRequestIDHeader(name string) {
+	requestIDHeaderMu.Lock()
+	defer requestIDHeaderMu.Unlock()
+	requestIDHeader = http.CanonicalHeaderKey(name)
+}
+
+// RequestIDHeader returns the header the middlewares read the request ID from
+func RequestIDHeader() string {
+	requestIDHeaderMu.RLock()
+	defer requestIDHeaderMu.RUnlock()
+	return requestIDHeader
+}
+
+// OtelLoggerConfig defines the config for OtelLoggerMiddleware.
+type OtelLoggerConfig struct {
+	// LinkHeaders lists request headers carrying W3C traceparent values, comma separated when several,
+	// e.g. the trace of the message that triggered the request. Each valid value is added to the
+	// current span as a link, recording fan-in causality. Malformed values are ignored.
+	LinkHeaders []string
+
+	// ExposeTraceHeaders writes X-Trace-Id and X-Trace-Sampled ("true" or "false") to the response
+	// when the request has a valid span, so clients can report the trace of a failed call.
+	ExposeTraceHeaders bool
+
+	// RecordSpanEvents adds a request.received event to the current span before the handler runs and a
+	// request.completed event carrying http.status_code after it returns, as timeline markers in the span.
+	RecordSpanEvents bool
+
+	// RequestIDGenerator, when set, generates a request ID for requests carrying none and writes it to the
+	// response header. Otherwise requests without an ID get no request.id span attribute.
+	RequestIDGenerator func() string
+
+	// ExtractTraceContext extracts the propagation context, e.g. traceparent, from the request headers with
+	// the global propagator when no tracing middleware has attached a span, so LoggerWithContext picks up the
+	// client's trace ID. Register a propagator with otel.SetTextMapPropagator; the default one extracts nothing.
+	ExtractTraceContext bool
+}
+
+const (
+	// HeaderXTraceID carries the trace ID of the request when OtelLoggerConfig.ExposeTraceHeaders is set
+	HeaderXTraceID = "X-Trace-Id"
+	// HeaderXTraceSampled reports whether the request trace was sampled when OtelLoggerConfig.ExposeTraceHeaders is set
+	HeaderXTraceSampled = "X-Trace-Sampled"
+)
+
 // OtelLoggerMiddleware is an Echo middleware that:
 // 1. Sets request_id as a span attribute for OpenTelemetry tracing
 // 2. Stores request_id in context for logger access
 func OtelLoggerMiddleware() echo.MiddlewareFunc {
+	return OtelLoggerMiddlewareWithConfig(OtelLoggerConfig{})
+}
+
+// OtelLoggerMiddlewareWithConfig returns an OtelLoggerMiddleware with config.
+func OtelLoggerMiddlewareWithConfig(config OtelLoggerConfig) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
+			// Continue the client's trace when no tracing middleware ran upstream
+			if config.ExtractTraceContext && !trace.SpanContextFromContext(c.Request().Context()).IsValid() {
+				ctx := otel.GetTextMapPropagator().Extract(c.Request().Context(), propagation.HeaderCarrier(c.Request().Header))
+				c.SetRequest(c.Request().WithContext(ctx))
+			}
+
 			// Get the current span from the request context
 			span := trace.SpanFromContext(c.Request().Context())
 
+			// Link the traces referenced by the configured headers
+			for _, link := range spanLinks(c.Request().Header, config.LinkHeaders) {
+				span.AddLink(link)
+			}
+
 			// Extract request ID from Echo's RequestID middleware
-			requestID := c.Response().Header().Get(echo.HeaderXRequestID)
+			requestID := c.Response().Header().Get(RequestIDHeader())
 			if requestID == "" {
-				requestID = c.Request().Header.Get(echo.HeaderXRequestID)
+				requestID = c.Request().Header.Get(RequestIDHeader())
+			}
+			if requestID == "" && config.RequestIDGenerator != nil {
+				requestID = config.RequestIDGenerator()
+				c.Response().Header().Set(RequestIDHeader(), requestID)
 			}
 
 			// Set request_id as a span attribute for distributed tracing
-			if span.SpanContext().IsValid() {
+			if span.SpanContext().IsValid() && requestID != "" {
 				span.SetAttributes(attribute.String(RequestIDAttribute, requestID))
 			}
 
+			// Echo the trace to the client
+			if config.ExposeTraceHeaders && span.SpanContext().IsValid() {
+				c.Response().Header().Set(HeaderXTraceID, span.SpanContext().TraceID().String())
+				c.Response().Header().Set(HeaderXTraceSampled, strconv.FormatBool(span.SpanContext().IsSampled()))
+			}
+
 			// Store request_id in context for logger access
 			ctx := context.WithValue(c.Request().Context(), requestIDContextKey, requestID)
 			c.SetRequest(c.Request().WithContext(ctx))
 
-			return next(c)
+			if !config.RecordSpanEvents {
+				return next(c)
+			}
+
+			span.AddEvent("request.received")
+			err := next(c)
+			span.AddEvent("request.completed", trace.WithAttributes(attribute.Int("http.status_code", completedStatus(c, err))))
+			return err
 		}
 	}
 }
 
+// completedStatus returns the status the request completes with, taking an uncommitted handler error into account
+func completedStatus(c echo.Context, err error) int {
+	if err == nil || c.Response().Committed {
+		return c.Response().Status
+	}
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.Code
+	}
+	return http.StatusInternalServerError
+}
+
+// spanLinks parses the traceparent values of the named headers into span links
+func spanLinks(header http.Header, names []string) []trace.Link {
+	var links []trace.Link
+	for _, name := range names {
+		for _, value := range header.Values(name) {
+			for _, traceparent := range strings.Split(value, ",") {
+				carrier := propagation.MapCarrier{"traceparent": strings.TrimSpace(traceparent)}
+				ctx := propagation.TraceContext{}.Extract(context.Background(), carrier)
+				if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+					links = append(links, trace.Link{SpanContext: spanContext})
+				}
+			}
+		}
+	}
+	return links
+}
+
 // LoggerWithContext is an Echo middleware that injects trace_id, span_id, and request_id into the logger
 // and stores the enhanced logger in the context for use across all layers (API -> Service -> Repository)
 func LoggerWithContext() echo.MiddlewareFunc {
@@ -66,10 +187,10 @@ func LoggerWithContext() echo.MiddlewareFunc {
 			}
 
 			// Extract request ID from Echo's RequestID middleware
-			requestID := c.Response().Header().Get(echo.HeaderXRequestID)
+			requestID := c.Response().Header().Get(RequestIDHeader())
 			if requestID == "" {
 				// Fallback: try to get from request header
-				requestID = c.Request().Header.Get(echo.HeaderXRequestID)
+				requestID = c.Request().Header.Get(RequestIDHeader())
 			}
 
 			// Create a new logger with trace_id, span_id, and request_id fields
@@ -109,6 +230,15 @@ func GetLogger(c echo.Context) *zap.SugaredLogger {
 	return zap.S()
 }
 
+// GetLoggerOK retrieves the logger from Echo context like GetLogger and reports whether the context held one.
+// A false result means the global logger was returned, e.g. because LoggerWithContext is not mounted.
+func GetLoggerOK(c echo.Context) (*zap.SugaredLogger, bool) {
+	if logger, ok := c.Get(loggerContextKey).(*zap.SugaredLogger); ok {
+		return logger, true
+	}
+	return zap.S(), false
+}
+
 // GetLoggerFromContext retrieves the logger with trace_id, span_id, and request_id from standard Go context
 // Use this in service and repository layers
 func GetLoggerFromContext(ctx context.Context) *zap.SugaredLogger {
@@ -119,6 +249,21 @@ func GetLoggerFromContext(ctx context.Context) *zap.SugaredLogger {
 	return zap.S()
 }
 
+// GetLoggerFromContextOK retrieves the logger from standard Go context like GetLoggerFromContext
+// and reports whether the context held one
+func GetLoggerFromContextOK(ctx context.Context) (*zap.SugaredLogger, bool) {
+	if logger, ok := ctx.Value(loggerContextKey).(*zap.SugaredLogger); ok {
+		return logger, true
+	}
+	return zap.S(), false
+}
+
+// GetLoggerFromRequest retrieves the logger with trace_id, span_id, and request_id from the request context
+// Use this in plain http.Handler endpoints mounted in Echo
+func GetLoggerFromRequest(r *http.Request) *zap.SugaredLogger {
+	return GetLoggerFromContext(r.Context())
+}
+
 // GetTraceID retrieves the trace ID from Echo context
 func GetTraceID(c echo.Context) string {
 	if traceID, ok := c.Get(traceIDContextKey).(string); ok {
@@ -166,3 +311,18 @@ func GetRequestIDFromContext(ctx context.Context) string {
 	}
 	return ""
 }
+
+// TraceField returns the trace_id field for structured *zap.Logger calls from standard Go context
+func TraceField(ctx context.Context) zap.Field {
+	return zap.String("trace_id", GetTraceIDFromContext(ctx))
+}
+
+// SpanField returns the span_id field for structured *zap.Logger calls from standard Go context
+func SpanField(ctx context.Context) zap.Field {
+	return zap.String("span_id", GetSpanIDFromContext(ctx))
+}
+
+// RequestIDField returns the request_id field for structured *zap.Logger calls from standard Go context
+func RequestIDField(ctx context.Context) zap.Field {
+	return zap.String("request_id", GetRequestIDFromContext(ctx))
+}
@@ -9,8 +9,14 @@ import (
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
 )
 
 func TestOtelLoggerMiddlewareStoresRequestIDFromResponse(t *testing.T) {
@@ -52,6 +58,151 @@ func TestOtelLoggerMiddlewareFallsBackToRequestHeader(t *testing.T) {
 	require.NoError(t, handler(c))
 }
 
+// linkRecordingSpan is a valid span that records the links added to it
+type linkRecordingSpan struct {
+	noop.Span
+	links []trace.Link
+}
+
+func (s *linkRecordingSpan) SpanContext() trace.SpanContext { return testSpanContext() }
+
+func (s *linkRecordingSpan) AddLink(link trace.Link) { s.links = append(s.links, link) }
+
+func TestOtelLoggerMiddlewareAddsSpanLinks(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/resource", nil)
+	req.Header.Set("X-Cause-Traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01, not-a-traceparent")
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	span := &linkRecordingSpan{}
+	c.SetRequest(req.WithContext(trace.ContextWithSpan(context.Background(), span)))
+
+	handler := OtelLoggerMiddlewareWithConfig(OtelLoggerConfig{LinkHeaders: []string{"X-Cause-Traceparent"}})(func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	require.NoError(t, handler(c))
+	require.Len(t, span.links, 1)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.links[0].SpanContext.TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", span.links[0].SpanContext.SpanID().String())
+	assert.True(t, span.links[0].SpanContext.IsRemote())
+}
+
+// eventRecordingSpan is a valid span that records the events added to it
+type eventRecordingSpan struct {
+	noop.Span
+	events []string
+	status int64
+}
+
+func (s *eventRecordingSpan) SpanContext() trace.SpanContext { return testSpanContext() }
+
+func (s *eventRecordingSpan) AddEvent(name string, options ...trace.EventOption) {
+	s.events = append(s.events, name)
+	config := trace.NewEventConfig(options...)
+	for _, attr := range config.Attributes() {
+		if attr.Key == "http.status_code" {
+			s.status = attr.Value.AsInt64()
+		}
+	}
+}
+
+func TestOtelLoggerMiddlewareRecordSpanEvents(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/resource", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	span := &eventRecordingSpan{}
+	c.SetRequest(req.WithContext(trace.ContextWithSpan(context.Background(), span)))
+
+	handler := OtelLoggerMiddlewareWithConfig(OtelLoggerConfig{RecordSpanEvents: true})(func(c echo.Context) error {
+		assert.Equal(t, []string{"request.received"}, span.events)
+		return echo.NewHTTPError(http.StatusNotFound)
+	})
+
+	require.Error(t, handler(c))
+	assert.Equal(t, []string{"request.received", "request.completed"}, span.events)
+	assert.Equal(t, int64(http.StatusNotFound), span.status)
+}
+
+func TestOtelLoggerMiddlewareEmptyRequestID(t *testing.T) {
+	tests := []struct {
+		name      string
+		generator func() string
+		expected  []attribute.KeyValue
+	}{
+		{name: "generation-off"},
+		{name: "generated", generator: func() string { return "generated-id" }, expected: []attribute.KeyValue{attribute.String(RequestIDAttribute, "generated-id")}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/resource", nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			span := &attributeRecordingSpan{}
+			c.SetRequest(req.WithContext(trace.ContextWithSpan(context.Background(), span)))
+
+			handler := OtelLoggerMiddlewareWithConfig(OtelLoggerConfig{RequestIDGenerator: tc.generator})(func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			})
+
+			require.NoError(t, handler(c))
+			assert.Equal(t, tc.expected, span.attributes)
+			if tc.generator != nil {
+				assert.Equal(t, "generated-id", rec.Header().Get(echo.HeaderXRequestID))
+			}
+		})
+	}
+}
+
+func TestOtelLoggerMiddlewareExtractTraceContext(t *testing.T) {
+	original := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTextMapPropagator(original) })
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/resource", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	middleware := OtelLoggerMiddlewareWithConfig(OtelLoggerConfig{ExtractTraceContext: true})
+	handler := middleware(LoggerWithContext()(func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	}))
+
+	require.NoError(t, handler(c))
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", c.Get(traceIDContextKey))
+}
+
+func TestOtelLoggerMiddlewareExposeTraceHeaders(t *testing.T) {
+	for _, expose := range []bool{false, true} {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/resource", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		spanCtx := testSpanContext()
+		c.SetRequest(req.WithContext(trace.ContextWithSpanContext(context.Background(), spanCtx)))
+
+		handler := OtelLoggerMiddlewareWithConfig(OtelLoggerConfig{ExposeTraceHeaders: expose})(func(c echo.Context) error {
+			return c.NoContent(http.StatusOK)
+		})
+		require.NoError(t, handler(c))
+
+		if expose {
+			assert.Equal(t, spanCtx.TraceID().String(), rec.Header().Get(HeaderXTraceID))
+			assert.Equal(t, "true", rec.Header().Get(HeaderXTraceSampled))
+		} else {
+			assert.Empty(t, rec.Header().Get(HeaderXTraceID))
+			assert.Empty(t, rec.Header().Get(HeaderXTraceSampled))
+		}
+	}
+}
+
 func TestLoggerWithContextPopulatesContext(t *testing.T) {
 	global := zap.NewExample()
 	undo := zap.ReplaceGlobals(global)
@@ -92,6 +243,35 @@ func TestLoggerWithContextPopulatesContext(t *testing.T) {
 	assert.Equal(t, "resp-id", GetRequestIDFromContext(gotCtx))
 }
 
+func TestGetLoggerFromRequestWithWrappedHandler(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/resource", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Response().Header().Set(echo.HeaderXRequestID, "resp-id")
+
+	var (
+		expected *zap.SugaredLogger
+		got      *zap.SugaredLogger
+	)
+	plain := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = GetLoggerFromRequest(r)
+	})
+	handler := LoggerWithContext()(func(c echo.Context) error {
+		expected = GetLogger(c)
+		return echo.WrapHandler(plain)(c)
+	})
+
+	require.NoError(t, handler(c))
+	require.NotNil(t, got)
+	assert.Equal(t, expected, got)
+
+	global := zap.NewExample()
+	undo := zap.ReplaceGlobals(global)
+	t.Cleanup(func() { undo() })
+	assert.Equal(t, global, GetLoggerFromRequest(httptest.NewRequest(http.MethodGet, "/", nil)).Desugar())
+}
+
 func TestLoggerWithContextFallsBackWithoutSpan(t *testing.T) {
 	e := echo.New()
 	req := httptest.NewRequest(http.MethodGet, "/resource", nil)
@@ -109,6 +289,30 @@ func TestLoggerWithContextFallsBackWithoutSpan(t *testing.T) {
 	require.NoError(t, handler(c))
 }
 
+func TestCustomRequestIDHeader(t *testing.T) {
+	SetRequestIDHeader("x-correlation-id")
+	t.Cleanup(func() { SetRequestIDHeader(echo.HeaderXRequestID) })
+	assert.Equal(t, "X-Correlation-Id", RequestIDHeader())
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/resource", nil)
+	req.Header.Set("X-Correlation-ID", "corr-id")
+	req.Header.Set(echo.HeaderXRequestID, "ignored")
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	core, obs := observer.New(zapcore.InfoLevel)
+	handler := ZapLogger(zap.New(core), nil)(OtelLoggerMiddleware()(LoggerWithContext()(func(c echo.Context) error {
+		assert.Equal(t, "corr-id", GetRequestID(c))
+		assert.Equal(t, "corr-id", GetRequestIDFromContext(c.Request().Context()))
+		return c.NoContent(http.StatusOK)
+	})))
+
+	require.NoError(t, handler(c))
+	require.Len(t, obs.All(), 1)
+	assert.Equal(t, "corr-id", obs.All()[0].ContextMap()["request_id"])
+}
+
 func TestLoggerHelpersFallbackToGlobals(t *testing.T) {
 	global := zap.NewExample()
 	undo := zap.ReplaceGlobals(global)
@@ -136,6 +340,67 @@ func TestLoggerHelpersFallbackToGlobals(t *testing.T) {
 	assert.Empty(t, GetRequestIDFromContext(ctx))
 }
 
+func TestCorrelationFieldsFromContext(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/resource", nil)
+	req.Header.Set(echo.HeaderXRequestID, "req-id")
+	c := e.NewContext(req, httptest.NewRecorder())
+	spanCtx := testSpanContext()
+	c.SetRequest(req.WithContext(trace.ContextWithSpanContext(context.Background(), spanCtx)))
+
+	var fields []zap.Field
+	handler := LoggerWithContext()(func(c echo.Context) error {
+		ctx := c.Request().Context()
+		fields = []zap.Field{TraceField(ctx), SpanField(ctx), RequestIDField(ctx)}
+		return nil
+	})
+	require.NoError(t, handler(c))
+
+	core, obs := observer.New(zapcore.InfoLevel)
+	zap.New(core).Info("query", fields...)
+	assert.Equal(t, map[string]interface{}{
+		"trace_id":   spanCtx.TraceID().String(),
+		"span_id":    spanCtx.SpanID().String(),
+		"request_id": "req-id",
+	}, obs.All()[0].ContextMap())
+
+	empty := context.Background()
+	assert.Equal(t, zap.String("trace_id", ""), TraceField(empty))
+}
+
+func TestGetLoggerOK(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/resource", nil)
+
+	t.Run("present", func(t *testing.T) {
+		c := e.NewContext(req, httptest.NewRecorder())
+		handler := LoggerWithContext()(func(c echo.Context) error {
+			logger, ok := GetLoggerOK(c)
+			assert.True(t, ok)
+			assert.Same(t, GetLogger(c), logger)
+
+			logger, ok = GetLoggerFromContextOK(c.Request().Context())
+			assert.True(t, ok)
+			assert.Same(t, GetLogger(c), logger)
+			return nil
+		})
+		require.NoError(t, handler(c))
+	})
+
+	t.Run("fallback", func(t *testing.T) {
+		c := e.NewContext(req, httptest.NewRecorder())
+		c.Set(loggerContextKey, zap.NewNop())
+
+		logger, ok := GetLoggerOK(c)
+		assert.False(t, ok)
+		assert.Same(t, zap.S(), logger)
+
+		logger, ok = GetLoggerFromContextOK(context.Background())
+		assert.False(t, ok)
+		assert.Same(t, zap.S(), logger)
+	})
+}
+
 func testSpanContext() trace.SpanContext {
 	return trace.NewSpanContext(trace.SpanContextConfig{
 		TraceID:    trace.TraceID{1, 2, 3, 4, 5, 6, 7, 8, 8, 7, 6, 5, 4, 3, 2, 1},
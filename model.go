@@ -9,4 +9,6 @@ type BodyDumpModel struct {
 	Status        int    `json:"status"`
 	Request       string `json:"request"`
 	Response      string `json:"response"`
+	Version       string `json:"version,omitempty"`
+	Commit        string `json:"commit,omitempty"`
 }
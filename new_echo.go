@@ -0,0 +1,32 @@
+package echomiddleware
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+// ZapLoggerOption customizes the ZapLogger config mounted by NewEcho
+type ZapLoggerOption func(config *ZapLoggerConfig)
+
+// NewEcho returns an Echo instance with the middleware stack of this package mounted in order:
+// RequestID, OtelLoggerMiddleware, LoggerWithContext, ZapLogger configured with log, collection, and
+// opts, then BodyDump logging through log. Middlewares added afterwards run inside this stack.
+func NewEcho(log *zap.Logger, collection *mongo.Collection, opts ...ZapLoggerOption) *echo.Echo {
+	config := ZapLoggerConfig{Logger: log, Collection: collection}
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	e := echo.New()
+	e.Use(middleware.RequestIDWithConfig(middleware.RequestIDConfig{
+		Generator:    generateRequestID,
+		TargetHeader: RequestIDHeader(),
+	}))
+	e.Use(OtelLoggerMiddleware())
+	e.Use(LoggerWithContext())
+	e.Use(ZapLoggerWithConfig(config))
+	e.Use(middleware.BodyDump(BodyDumpWithConfig(BodyDumpConfig{Logger: config.Logger})))
+	return e
+}
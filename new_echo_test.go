@@ -0,0 +1,52 @@
+package echomiddleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestNewEchoProducesCorrelatedLogs(t *testing.T) {
+	viper.Set("ENVIRONMENT", "development")
+	t.Cleanup(func() { viper.Set("ENVIRONMENT", "") })
+
+	core, obs := observer.New(zapcore.InfoLevel)
+	logger := zap.New(core)
+	undo := zap.ReplaceGlobals(logger)
+	t.Cleanup(undo)
+
+	e := NewEcho(logger, nil, func(config *ZapLoggerConfig) {
+		config.RequestHeaderFields = []string{"X-Tenant-ID"}
+	})
+	e.GET("/orders/:id", func(c echo.Context) error {
+		GetLogger(c).Info("Loading order")
+		return c.String(http.StatusOK, "order")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/orders/7", nil)
+	req.Header.Set("X-Tenant-ID", "acme")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	requestID := rec.Header().Get(echo.HeaderXRequestID)
+	require.Len(t, requestID, 32)
+
+	handlerLog := obs.FilterMessage("Loading order").All()
+	require.Len(t, handlerLog, 1)
+	assert.Equal(t, requestID, handlerLog[0].ContextMap()["request_id"])
+
+	accessLog := obs.FilterMessage("Success").All()
+	require.Len(t, accessLog, 1)
+	assert.Equal(t, requestID, accessLog[0].ContextMap()["request_id"])
+	assert.Equal(t, "acme", accessLog[0].ContextMap()["req.x-tenant-id"])
+
+	assert.Len(t, obs.FilterMessageSnippet("Body dump: ").All(), 1)
+}
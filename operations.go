@@ -0,0 +1,77 @@
+package echomiddleware
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// operationStats accumulates the downstream operations recorded for a request
+type operationStats struct {
+	slowestName    string
+	slowestLatency time.Duration
+	total          time.Duration
+	count          int
+}
+
+func (s *operationStats) record(name string, duration time.Duration) {
+	if s.count == 0 || duration > s.slowestLatency {
+		s.slowestName = name
+		s.slowestLatency = duration
+	}
+	s.total += duration
+	s.count++
+}
+
+func (s *operationStats) fields() []zapcore.Field {
+	if s.count == 0 {
+		return nil
+	}
+	return []zapcore.Field{
+		zap.String("slowest_operation", s.slowestName),
+		zap.String("slowest_operation_latency", s.slowestLatency.String()),
+		zap.String("downstream_latency", s.total.String()),
+		zap.Int("operation_count", s.count),
+	}
+}
+
+// dbStats accumulates the database queries recorded for a request
+type dbStats struct {
+	count int
+	total time.Duration
+}
+
+func (s *dbStats) fields() []zapcore.Field {
+	if s.count == 0 {
+		return nil
+	}
+	return []zapcore.Field{
+		zap.Int("db_query_count", s.count),
+		zap.Float64("db_time_ms", float64(s.total)/float64(time.Millisecond)),
+	}
+}
+
+// RecordOperation records a downstream operation, e.g. a database query or an HTTP call, for the
+// current request. ZapLogger logs the slowest operation and the total downstream time.
+// It is a no-op when ZapLogger is not mounted.
+func RecordOperation(ctx context.Context, name string, duration time.Duration) {
+	if state := logStateFromContext(ctx); state != nil {
+		state.mu.Lock()
+		defer state.mu.Unlock()
+		state.operations.record(name, duration)
+	}
+}
+
+// IncDBQuery counts a database query that took duration for the current request.
+// ZapLogger logs the query count as db_query_count and their total time as db_time_ms,
+// surfacing N+1 query patterns. It is a no-op when ZapLogger is not mounted.
+func IncDBQuery(ctx context.Context, duration time.Duration) {
+	if state := logStateFromContext(ctx); state != nil {
+		state.mu.Lock()
+		defer state.mu.Unlock()
+		state.db.count++
+		state.db.total += duration
+	}
+}
@@ -0,0 +1,64 @@
+package echomiddleware
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestRecordOperationLogsSlowest(t *testing.T) {
+	_, c, _ := newTestContext(t, http.MethodGet, "/test/123", "")
+
+	core, obs := observer.New(zapcore.InfoLevel)
+	middleware := ZapLogger(zap.New(core), nil)
+	handler := middleware(func(c echo.Context) error {
+		RecordOperation(c.Request().Context(), "users.find", 20*time.Millisecond)
+		RecordOperation(c.Request().Context(), "billing.get", 50*time.Millisecond)
+		return c.NoContent(http.StatusOK)
+	})
+
+	require.NoError(t, handler(c))
+
+	require.Len(t, obs.All(), 1)
+	fields := obs.All()[0].ContextMap()
+	assert.Equal(t, "billing.get", fields["slowest_operation"])
+	assert.Equal(t, "50ms", fields["slowest_operation_latency"])
+	assert.Equal(t, "70ms", fields["downstream_latency"])
+	assert.Equal(t, int64(2), fields["operation_count"])
+}
+
+func TestIncDBQueryAccumulates(t *testing.T) {
+	_, c, _ := newTestContext(t, http.MethodGet, "/test/123", "")
+
+	core, obs := observer.New(zapcore.InfoLevel)
+	middleware := ZapLogger(zap.New(core), nil)
+	handler := middleware(func(c echo.Context) error {
+		for i := 0; i < 3; i++ {
+			IncDBQuery(c.Request().Context(), 1500*time.Microsecond)
+		}
+		return c.NoContent(http.StatusOK)
+	})
+
+	require.NoError(t, handler(c))
+
+	require.Len(t, obs.All(), 1)
+	fields := obs.All()[0].ContextMap()
+	assert.Equal(t, int64(3), fields["db_query_count"])
+	assert.InDelta(t, 4.5, fields["db_time_ms"], 1e-9)
+	assert.NotContains(t, fields, "slowest_operation")
+}
+
+func TestRecordOperationWithoutZapLoggerIsNoop(t *testing.T) {
+	assert.NotPanics(t, func() {
+		RecordOperation(context.Background(), "users.find", time.Millisecond)
+		IncDBQuery(context.Background(), time.Millisecond)
+	})
+}
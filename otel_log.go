@@ -0,0 +1,90 @@
+package echomiddleware
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	otellog "go.opentelemetry.io/otel/log"
+	"go.uber.org/zap/zapcore"
+)
+
+// emitOTelRecord emits an access log entry as an OpenTelemetry log record.
+// ctx carries the active span, which the logs SDK uses for trace correlation.
+func emitOTelRecord(ctx context.Context, logger otellog.Logger, level zapcore.Level, message string, fields []zapcore.Field) {
+	now := time.Now()
+
+	var record otellog.Record
+	record.SetTimestamp(now)
+	record.SetObservedTimestamp(now)
+	record.SetSeverity(otelSeverity(level))
+	record.SetSeverityText(level.CapitalString())
+	record.SetBody(otellog.StringValue(message))
+
+	document := zapFieldsToMap(fields)
+	keys := make([]string, 0, len(document))
+	for key := range document {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	attributes := make([]otellog.KeyValue, 0, len(keys))
+	for _, key := range keys {
+		attributes = append(attributes, otellog.KeyValue{Key: key, Value: otelValue(document[key])})
+	}
+	record.AddAttributes(attributes...)
+
+	logger.Emit(ctx, record)
+}
+
+func otelSeverity(level zapcore.Level) otellog.Severity {
+	switch level {
+	case zapcore.DebugLevel:
+		return otellog.SeverityDebug
+	case zapcore.InfoLevel:
+		return otellog.SeverityInfo
+	case zapcore.WarnLevel:
+		return otellog.SeverityWarn
+	case zapcore.ErrorLevel:
+		return otellog.SeverityError
+	default:
+		return otellog.SeverityFatal
+	}
+}
+
+func otelValue(value interface{}) otellog.Value {
+	switch v := value.(type) {
+	case string:
+		return otellog.StringValue(v)
+	case int64:
+		return otellog.Int64Value(v)
+	case int:
+		return otellog.IntValue(v)
+	case float64:
+		return otellog.Float64Value(v)
+	case bool:
+		return otellog.BoolValue(v)
+	case []byte:
+		return otellog.BytesValue(v)
+	case []interface{}:
+		values := make([]otellog.Value, 0, len(v))
+		for _, element := range v {
+			values = append(values, otelValue(element))
+		}
+		return otellog.SliceValue(values...)
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		values := make([]otellog.KeyValue, 0, len(keys))
+		for _, key := range keys {
+			values = append(values, otellog.KeyValue{Key: key, Value: otelValue(v[key])})
+		}
+		return otellog.MapValue(values...)
+	default:
+		return otellog.StringValue(fmt.Sprint(v))
+	}
+}
@@ -0,0 +1,73 @@
+package echomiddleware
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	otellog "go.opentelemetry.io/otel/log"
+	"go.opentelemetry.io/otel/log/embedded"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+type emittedRecord struct {
+	ctx    context.Context
+	record otellog.Record
+}
+
+type recordingOTelLogger struct {
+	embedded.Logger
+
+	mu      sync.Mutex
+	records []emittedRecord
+}
+
+func (l *recordingOTelLogger) Emit(ctx context.Context, record otellog.Record) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.records = append(l.records, emittedRecord{ctx: ctx, record: record.Clone()})
+}
+
+func (l *recordingOTelLogger) Enabled(context.Context, otellog.EnabledParameters) bool {
+	return true
+}
+
+func recordAttributes(record otellog.Record) map[string]otellog.Value {
+	attributes := make(map[string]otellog.Value, record.AttributesLen())
+	record.WalkAttributes(func(kv otellog.KeyValue) bool {
+		attributes[kv.Key] = kv.Value
+		return true
+	})
+	return attributes
+}
+
+func TestZapLoggerEmitsOTelRecordWithTraceID(t *testing.T) {
+	_, c, _ := newTestContext(t, http.MethodGet, "/test/123", "")
+
+	otelLogger := &recordingOTelLogger{}
+	middleware := ZapLoggerWithConfig(ZapLoggerConfig{Logger: zap.NewNop(), OTelLogger: otelLogger})
+	handler := middleware(func(c echo.Context) error {
+		return c.String(http.StatusNotFound, "missing")
+	})
+
+	require.NoError(t, handler(c))
+
+	require.Len(t, otelLogger.records, 1)
+	emitted := otelLogger.records[0]
+	assert.Equal(t, otellog.SeverityWarn, emitted.record.Severity())
+	assert.Equal(t, "Client error", emitted.record.Body().AsString())
+
+	spanCtx := trace.SpanContextFromContext(emitted.ctx)
+	require.True(t, spanCtx.IsValid())
+	assert.Equal(t, "00010203040506070706050403020100", spanCtx.TraceID().String())
+
+	attributes := recordAttributes(emitted.record)
+	assert.Equal(t, int64(http.StatusNotFound), attributes["status"].AsInt64())
+	assert.Equal(t, spanCtx.SpanID().String(), attributes["span_id"].AsString())
+	assert.Equal(t, "missing", attributes["response"].AsString())
+}
@@ -0,0 +1,36 @@
+package echomiddleware
+
+import "regexp"
+
+// piiMask replaces every PII match
+const piiMask = "***"
+
+var (
+	// EmailPattern matches email addresses
+	EmailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	// CardNumberPattern matches 13 to 16 digit numbers such as payment card numbers
+	CardNumberPattern = regexp.MustCompile(`\b\d{13,16}\b`)
+)
+
+// PIIMasker replaces regex matches in free-form text with "***"
+type PIIMasker struct {
+	patterns []*regexp.Regexp
+}
+
+// NewPIIMasker returns a PIIMasker applying the given patterns
+func NewPIIMasker(patterns ...*regexp.Regexp) *PIIMasker {
+	return &PIIMasker{patterns: patterns}
+}
+
+// DefaultPIIMasker returns a PIIMasker for email addresses and card numbers
+func DefaultPIIMasker() *PIIMasker {
+	return NewPIIMasker(EmailPattern, CardNumberPattern)
+}
+
+// Mask returns s with every pattern match replaced
+func (m *PIIMasker) Mask(s string) string {
+	for _, pattern := range m.patterns {
+		s = pattern.ReplaceAllString(s, piiMask)
+	}
+	return s
+}
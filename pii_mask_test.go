@@ -0,0 +1,51 @@
+package echomiddleware
+
+import (
+	"net/http"
+	"regexp"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestPIIMaskerMasksEmail(t *testing.T) {
+	masker := DefaultPIIMasker()
+	assert.Equal(t, "contact *** for help", masker.Mask("contact jane.doe+test@example.co.th for help"))
+}
+
+func TestPIIMaskerMasksCardNumber(t *testing.T) {
+	masker := DefaultPIIMasker()
+	assert.Equal(t, "card=*** exp=12/30", masker.Mask("card=4111111111111111 exp=12/30"))
+	assert.Equal(t, "order 123456", masker.Mask("order 123456"))
+}
+
+func TestPIIMaskerCustomPatterns(t *testing.T) {
+	masker := NewPIIMasker(regexp.MustCompile(`secret-\w+`))
+	assert.Equal(t, "token *** user@example.com", masker.Mask("token secret-abc user@example.com"))
+}
+
+func TestZapLoggerMasksPII(t *testing.T) {
+	_, c, _ := newTestContext(t, http.MethodPost, "/test/123", "email=jane@example.com")
+
+	core, obs := observer.New(zapcore.InfoLevel)
+	middleware := ZapLoggerWithConfig(ZapLoggerConfig{
+		Logger:    zap.New(core),
+		PIIMasker: DefaultPIIMasker(),
+	})
+	handler := middleware(func(c echo.Context) error {
+		return c.String(http.StatusOK, "charged 4111111111111111")
+	})
+
+	require.NoError(t, handler(c))
+
+	entries := obs.All()
+	require.Len(t, entries, 1)
+	fields := entries[0].ContextMap()
+	assert.Equal(t, "email=***", fields["body"])
+	assert.Equal(t, "charged ***", fields["response"])
+}
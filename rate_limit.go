@@ -0,0 +1,74 @@
+package echomiddleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// rateLimitHeaders maps the X-RateLimit-* response headers to the fields they are logged as
+var rateLimitHeaders = []struct{ header, key string }{
+	{"X-RateLimit-Limit", "rate_limit_limit"},
+	{"X-RateLimit-Remaining", "rate_limit_remaining"},
+	{"X-RateLimit-Reset", "rate_limit_reset"},
+}
+
+// rateLimitFields returns the limiter's reason, Retry-After, and X-RateLimit-* headers of a 429 response.
+// Retry-After is logged as retry_after and, when it holds delay seconds or an HTTP date, as retry_after_seconds.
+func rateLimitFields(status int, header http.Header, err error, now time.Time) []zapcore.Field {
+	if status != http.StatusTooManyRequests {
+		return nil
+	}
+	var fields []zapcore.Field
+	if reason := rateLimitReason(err); reason != "" {
+		fields = append(fields, zap.String("rate_limit_reason", reason))
+	}
+	if retryAfter := header.Get(echo.HeaderRetryAfter); retryAfter != "" {
+		fields = append(fields, zap.String("retry_after", retryAfter))
+		if seconds, ok := retryAfterSeconds(retryAfter, now); ok {
+			fields = append(fields, zap.Int64("retry_after_seconds", seconds))
+		}
+	}
+	for _, h := range rateLimitHeaders {
+		value := header.Get(h.header)
+		if value == "" {
+			continue
+		}
+		if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+			fields = append(fields, zap.Int64(h.key, n))
+		} else {
+			fields = append(fields, zap.String(h.key, value))
+		}
+	}
+	return fields
+}
+
+func rateLimitReason(err error) string {
+	if err == nil {
+		return ""
+	}
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) {
+		return fmt.Sprint(httpErr.Message)
+	}
+	return err.Error()
+}
+
+func retryAfterSeconds(value string, now time.Time) (int64, bool) {
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil && seconds >= 0 {
+		return seconds, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if delay := date.Sub(now); delay > 0 {
+			return int64(delay.Round(time.Second) / time.Second), true
+		}
+		return 0, true
+	}
+	return 0, false
+}
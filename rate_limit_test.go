@@ -0,0 +1,50 @@
+package echomiddleware
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestZapLoggerLogsRateLimitFields(t *testing.T) {
+	_, c, _ := newTestContext(t, http.MethodGet, "/test/123", "")
+
+	core, obs := observer.New(zapcore.InfoLevel)
+	middleware := ZapLogger(zap.New(core), nil)
+	handler := middleware(func(c echo.Context) error {
+		c.Response().Header().Set(echo.HeaderRetryAfter, "30")
+		c.Response().Header().Set("X-RateLimit-Limit", "100")
+		c.Response().Header().Set("X-RateLimit-Remaining", "0")
+		return echo.NewHTTPError(http.StatusTooManyRequests, "rate limit exceeded")
+	})
+	require.NoError(t, handler(c))
+
+	require.Len(t, obs.All(), 1)
+	fields := obs.All()[0].ContextMap()
+	assert.Equal(t, int64(http.StatusTooManyRequests), fields["status"])
+	assert.Equal(t, "rate limit exceeded", fields["rate_limit_reason"])
+	assert.Equal(t, "30", fields["retry_after"])
+	assert.Equal(t, int64(30), fields["retry_after_seconds"])
+	assert.Equal(t, int64(100), fields["rate_limit_limit"])
+	assert.Equal(t, int64(0), fields["rate_limit_remaining"])
+	assert.NotContains(t, fields, "rate_limit_reset")
+}
+
+func TestRetryAfterSeconds(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	seconds, ok := retryAfterSeconds(now.Add(90*time.Second).Format(http.TimeFormat), now)
+	assert.True(t, ok)
+	assert.Equal(t, int64(90), seconds)
+
+	_, ok = retryAfterSeconds("soon", now)
+	assert.False(t, ok)
+	assert.Nil(t, rateLimitFields(http.StatusOK, http.Header{"Retry-After": {"30"}}, nil, now))
+}
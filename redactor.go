@@ -0,0 +1,66 @@
+package echomiddleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// Redactor rewrites request and response bodies before ZapLogger logs them.
+// contentType is the Content-Type header of the request or response the body belongs to.
+type Redactor interface {
+	Redact(contentType string, body []byte) []byte
+}
+
+// JSONKeyRedactor masks the values of configured keys, at any depth, in JSON bodies.
+// Bodies of other content types and invalid JSON are returned unchanged.
+type JSONKeyRedactor struct {
+	keys map[string]struct{}
+}
+
+// NewJSONKeyRedactor returns a JSONKeyRedactor for the given keys, matched case-insensitively
+func NewJSONKeyRedactor(keys ...string) *JSONKeyRedactor {
+	redactor := &JSONKeyRedactor{keys: make(map[string]struct{}, len(keys))}
+	for _, key := range keys {
+		redactor.keys[strings.ToLower(key)] = struct{}{}
+	}
+	return redactor
+}
+
+// Redact implements Redactor
+func (r *JSONKeyRedactor) Redact(contentType string, body []byte) []byte {
+	if len(body) == 0 || !isJSONContentType(contentType) {
+		return body
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return body
+	}
+
+	redacted, err := json.Marshal(r.redactValue(value))
+	if err != nil {
+		return body
+	}
+	return redacted
+}
+
+func (r *JSONKeyRedactor) redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if _, ok := r.keys[strings.ToLower(key)]; ok {
+				v[key] = piiMask
+				continue
+			}
+			v[key] = r.redactValue(item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = r.redactValue(item)
+		}
+	}
+	return value
+}
@@ -0,0 +1,53 @@
+package echomiddleware
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestJSONKeyRedactorRedactsNestedKeys(t *testing.T) {
+	redactor := NewJSONKeyRedactor("password", "Token")
+
+	body := []byte(`{"user":"jane","password":"s3cret","items":[{"token":"abc","id":1}]}`)
+	redacted := redactor.Redact("application/json; charset=utf-8", body)
+
+	assert.JSONEq(t, `{"user":"jane","password":"***","items":[{"token":"***","id":1}]}`, string(redacted))
+}
+
+func TestJSONKeyRedactorPassesThroughOtherContent(t *testing.T) {
+	redactor := NewJSONKeyRedactor("password")
+
+	xml := []byte(`<login><password>s3cret</password></login>`)
+	assert.Equal(t, xml, redactor.Redact("application/xml", xml))
+
+	invalid := []byte(`{"password":`)
+	assert.Equal(t, invalid, redactor.Redact("application/json", invalid))
+}
+
+func TestZapLoggerAppliesRedactor(t *testing.T) {
+	_, c, _ := newTestContext(t, http.MethodPost, "/test/123", `{"password":"s3cret"}`)
+
+	core, obs := observer.New(zapcore.InfoLevel)
+	middleware := ZapLoggerWithConfig(ZapLoggerConfig{
+		Logger:   zap.New(core),
+		Redactor: NewJSONKeyRedactor("password", "token"),
+	})
+	handler := middleware(func(c echo.Context) error {
+		return c.JSON(http.StatusOK, map[string]string{"token": "abc"})
+	})
+
+	require.NoError(t, handler(c))
+
+	entries := obs.All()
+	require.Len(t, entries, 1)
+	fields := entries[0].ContextMap()
+	assert.JSONEq(t, `{"password":"***"}`, fields["body"].(string))
+	assert.JSONEq(t, `{"token":"***"}`, fields["response"].(string))
+}
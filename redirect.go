@@ -0,0 +1,44 @@
+package echomiddleware
+
+import (
+	"net"
+	"net/url"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// redirectFields returns redirect_to for 3xx responses carrying a Location, with redirect_external set
+// when the target names a host other than the request host. Relative targets are same-origin, while
+// scheme-relative ones such as //evil.example name a host and are compared like absolute URLs.
+// Backslashes are read as slashes, as browsers do, so /\evil.example is scheme-relative too. Schemes
+// other than http and https, and opaque targets such as https:evil.example, are always external.
+func redirectFields(status int, location, requestHost string) []zapcore.Field {
+	if status/100 != 3 || location == "" {
+		return nil
+	}
+	return []zapcore.Field{
+		zap.String("redirect_to", location),
+		zap.Bool("redirect_external", externalRedirect(location, requestHost)),
+	}
+}
+
+func externalRedirect(location, requestHost string) bool {
+	target, err := url.Parse(strings.ReplaceAll(location, `\`, "/"))
+	if err != nil || target.Opaque != "" {
+		return true
+	}
+	if scheme := strings.ToLower(target.Scheme); scheme != "" && scheme != "http" && scheme != "https" {
+		return true
+	}
+	return target.Host != "" && !strings.EqualFold(stripDefaultPort(target.Host), stripDefaultPort(requestHost))
+}
+
+// stripDefaultPort drops an :80 or :443 port so api.example.com:443 matches api.example.com
+func stripDefaultPort(host string) string {
+	if name, port, err := net.SplitHostPort(host); err == nil && (port == "80" || port == "443") {
+		return name
+	}
+	return host
+}
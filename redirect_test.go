@@ -0,0 +1,52 @@
+package echomiddleware
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestZapLoggerFlagsExternalRedirect(t *testing.T) {
+	_, c, _ := newTestContext(t, http.MethodGet, "/test/123", "")
+
+	core, obs := observer.New(zapcore.InfoLevel)
+	middleware := ZapLogger(zap.New(core), nil)
+	handler := middleware(func(c echo.Context) error {
+		return c.Redirect(http.StatusFound, "https://evil.example/login")
+	})
+	require.NoError(t, handler(c))
+
+	fields := obs.All()[0].ContextMap()
+	assert.Equal(t, "https://evil.example/login", fields["redirect_to"])
+	assert.Equal(t, true, fields["redirect_external"])
+}
+
+func TestRedirectFields(t *testing.T) {
+	external := func(location string) interface{} {
+		enc := zapcore.NewMapObjectEncoder()
+		for _, field := range redirectFields(http.StatusFound, location, "example.local") {
+			field.AddTo(enc)
+		}
+		return enc.Fields["redirect_external"]
+	}
+	assert.Equal(t, false, external("/next"))
+	assert.Equal(t, false, external("https://EXAMPLE.local/next"))
+	assert.Equal(t, true, external("//evil.example/next"))
+	assert.Equal(t, true, external(`/\evil.example/x`))
+	assert.Equal(t, true, external(`\\evil.example/x`))
+	assert.Equal(t, true, external("https:evil.example"))
+	assert.Equal(t, true, external("javascript:alert(1)"))
+	assert.Equal(t, true, external("ftp://example.local/file"))
+	assert.Equal(t, false, external("https://example.local:443/next"))
+	assert.Equal(t, true, external("https://example.local:8443/next"))
+	assert.Equal(t, false, external("?page=2"))
+	assert.False(t, externalRedirect("https://api.example.com/next", "api.example.com:443"))
+	assert.Nil(t, redirectFields(http.StatusOK, "/next", "example.local"))
+	assert.Nil(t, redirectFields(http.StatusFound, "", "example.local"))
+}
@@ -0,0 +1,67 @@
+package echomiddleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// RequireCorrelationConfig defines the config for RequireCorrelation middleware.
+type RequireCorrelationConfig struct {
+	// Logger receives the violations. Defaults to zap.L().
+	Logger *zap.Logger
+
+	// Headers lists the required request headers. Defaults to the request ID header
+	// (see SetRequestIDHeader) and traceparent.
+	Headers []string
+
+	// Reject answers requests missing a required header with 400 instead of passing them on.
+	Reject bool
+}
+
+// RequireCorrelation returns a middleware that checks every request carries the correlation headers.
+// Requests missing any of them are logged with the missing headers and whichever correlation IDs they do
+// carry, then rejected with 400 when config.Reject is set.
+func RequireCorrelation(config RequireCorrelationConfig) echo.MiddlewareFunc {
+	log := config.Logger
+	if log == nil {
+		log = zap.L()
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			headers := config.Headers
+			if len(headers) == 0 {
+				headers = []string{RequestIDHeader(), "traceparent"}
+			}
+
+			var missing []string
+			for _, header := range headers {
+				if req.Header.Get(header) == "" {
+					missing = append(missing, http.CanonicalHeaderKey(header))
+				}
+			}
+			if len(missing) == 0 {
+				return next(c)
+			}
+
+			traceID, _ := correlationIDs(req.Context())
+			log.Warn("Missing correlation headers",
+				zap.Strings("missing", missing),
+				zap.String("method", req.Method),
+				zap.String("path", c.Path()),
+				zap.String("remote_ip", c.RealIP()),
+				zap.String("request_id", req.Header.Get(RequestIDHeader())),
+				zap.String("trace_id", traceID),
+				zap.Bool("rejected", config.Reject),
+			)
+			if config.Reject {
+				return echo.NewHTTPError(http.StatusBadRequest, "missing correlation headers: "+strings.Join(missing, ", "))
+			}
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package echomiddleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestRequireCorrelation(t *testing.T) {
+	tests := []struct {
+		name        string
+		reject      bool
+		traceparent string
+		status      int
+		violations  int
+	}{
+		{name: "complete", traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", reject: true, status: http.StatusOK},
+		{name: "missing-logged", status: http.StatusOK, violations: 1},
+		{name: "missing-rejected", reject: true, status: http.StatusBadRequest, violations: 1},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			core, obs := observer.New(zapcore.InfoLevel)
+
+			e := echo.New()
+			e.Use(RequireCorrelation(RequireCorrelationConfig{Logger: zap.New(core), Reject: tc.reject}))
+			e.GET("/orders", func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+			req.Header.Set(echo.HeaderXRequestID, "req-1")
+			if tc.traceparent != "" {
+				req.Header.Set("traceparent", tc.traceparent)
+			}
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.status, rec.Code)
+			entries := obs.FilterMessage("Missing correlation headers").All()
+			require.Len(t, entries, tc.violations)
+			if tc.violations > 0 {
+				fields := entries[0].ContextMap()
+				assert.Equal(t, []interface{}{"Traceparent"}, fields["missing"])
+				assert.Equal(t, "req-1", fields["request_id"])
+				assert.Equal(t, "/orders", fields["path"])
+				assert.Equal(t, tc.reject, fields["rejected"])
+			}
+		})
+	}
+}
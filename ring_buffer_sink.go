@@ -0,0 +1,50 @@
+package echomiddleware
+
+import (
+	"context"
+	"sync"
+)
+
+// RingBufferSink is an in-memory LogSink that keeps the most recent documents.
+// It is intended for debugging endpoints such as /debug/logs and is safe for concurrent use.
+type RingBufferSink struct {
+	mu        sync.Mutex
+	documents []map[string]interface{}
+	next      int
+	full      bool
+}
+
+// NewRingBufferSink returns a RingBufferSink that keeps the last n documents.
+// A non-positive n keeps a single document.
+func NewRingBufferSink(n int) *RingBufferSink {
+	if n < 1 {
+		n = 1
+	}
+	return &RingBufferSink{documents: make([]map[string]interface{}, n)}
+}
+
+// Insert stores the document, overwriting the oldest one when the buffer is full
+func (s *RingBufferSink) Insert(_ context.Context, document map[string]interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.documents[s.next] = document
+	s.next = (s.next + 1) % len(s.documents)
+	if s.next == 0 {
+		s.full = true
+	}
+	return nil
+}
+
+// Snapshot returns the stored documents ordered from oldest to newest
+func (s *RingBufferSink) Snapshot() []map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.full {
+		return append([]map[string]interface{}(nil), s.documents[:s.next]...)
+	}
+	snapshot := make([]map[string]interface{}, 0, len(s.documents))
+	snapshot = append(snapshot, s.documents[s.next:]...)
+	return append(snapshot, s.documents[:s.next]...)
+}
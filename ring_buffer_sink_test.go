@@ -0,0 +1,71 @@
+package echomiddleware
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestRingBufferSinkWrapsAround(t *testing.T) {
+	sink := NewRingBufferSink(3)
+	assert.Empty(t, sink.Snapshot())
+
+	for i := 1; i <= 5; i++ {
+		require.NoError(t, sink.Insert(context.Background(), map[string]interface{}{"n": i}))
+	}
+
+	snapshot := sink.Snapshot()
+	require.Len(t, snapshot, 3)
+	assert.Equal(t, 3, snapshot[0]["n"])
+	assert.Equal(t, 4, snapshot[1]["n"])
+	assert.Equal(t, 5, snapshot[2]["n"])
+}
+
+func TestRingBufferSinkPartiallyFilled(t *testing.T) {
+	sink := NewRingBufferSink(3)
+	require.NoError(t, sink.Insert(context.Background(), map[string]interface{}{"n": 1}))
+	require.NoError(t, sink.Insert(context.Background(), map[string]interface{}{"n": 2}))
+
+	snapshot := sink.Snapshot()
+	require.Len(t, snapshot, 2)
+	assert.Equal(t, 1, snapshot[0]["n"])
+	assert.Equal(t, 2, snapshot[1]["n"])
+}
+
+func TestRingBufferSinkConcurrentInsert(t *testing.T) {
+	sink := NewRingBufferSink(10)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_ = sink.Insert(context.Background(), map[string]interface{}{"n": i})
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Len(t, sink.Snapshot(), 10)
+}
+
+func TestZapLoggerWritesToRingBufferSink(t *testing.T) {
+	_, c, _ := newTestContext(t, http.MethodGet, "/test/123", "")
+
+	sink := NewRingBufferSink(2)
+	middleware := ZapLoggerWithConfig(ZapLoggerConfig{Logger: zap.NewNop(), Sink: sink})
+	handler := middleware(func(c echo.Context) error {
+		return c.String(http.StatusOK, "ok")
+	})
+
+	require.NoError(t, handler(c))
+
+	require.Eventually(t, func() bool { return len(sink.Snapshot()) == 1 }, time.Second, time.Millisecond)
+	assert.Equal(t, "ok", sink.Snapshot()[0]["response"])
+}
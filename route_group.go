@@ -0,0 +1,21 @@
+package echomiddleware
+
+import "strings"
+
+// routeGroup returns the longest group prefix the route template belongs to.
+// Echo flattens groups into full route paths when routes are registered and keeps no group
+// reference on the route, so the group is identified by matching the template against the
+// configured prefixes on path segment boundaries: /api/v1 matches /api/v1/users but not /api/v10.
+func routeGroup(path string, groups []string) string {
+	var matched string
+	for _, group := range groups {
+		group = strings.TrimSuffix(group, "/")
+		if len(group) <= len(matched) || !strings.HasPrefix(path, group) {
+			continue
+		}
+		if len(path) == len(group) || path[len(group)] == '/' {
+			matched = group
+		}
+	}
+	return matched
+}
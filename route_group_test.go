@@ -0,0 +1,49 @@
+package echomiddleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestZapLoggerLogsRouteGroup(t *testing.T) {
+	core, obs := observer.New(zapcore.InfoLevel)
+
+	e := echo.New()
+	e.Use(ZapLoggerWithConfig(ZapLoggerConfig{
+		Logger:      zap.New(core),
+		RouteGroups: []string{"/api", "/api/v1", "/api/v2"},
+	}))
+	v1 := e.Group("/api/v1")
+	v1.GET("/users/:id", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+	e.GET("/status", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	for _, target := range []string{"/api/v1/users/42", "/status"} {
+		e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+	}
+
+	entries := obs.All()
+	require.Len(t, entries, 2)
+	assert.Equal(t, "/api/v1", entries[0].ContextMap()["route_group"])
+	assert.Equal(t, "/api/v1/users/:id", entries[0].ContextMap()["path"])
+	assert.NotContains(t, entries[1].ContextMap(), "route_group")
+}
+
+func TestRouteGroupMatchesSegmentBoundaries(t *testing.T) {
+	groups := []string{"/api/v1/", "/admin"}
+	assert.Equal(t, "/api/v1", routeGroup("/api/v1/users", groups))
+	assert.Equal(t, "/api/v1", routeGroup("/api/v1", groups))
+	assert.Equal(t, "", routeGroup("/api/v10/users", groups))
+	assert.Equal(t, "/admin", routeGroup("/admin/*", groups))
+}
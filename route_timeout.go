@@ -0,0 +1,54 @@
+package echomiddleware
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// RouteTimeout returns a middleware that applies a per-route deadline to the request context.
+// The timeout is looked up by the route template from c.Path(), falling back to defaultTimeout;
+// a non-positive timeout disables the deadline. Handlers must honor the context for the deadline
+// to take effect. Requests that exceed it are logged with their route and answered with 503 when
+// the handler has not written a response.
+func RouteTimeout(timeouts map[string]time.Duration, defaultTimeout time.Duration, log *zap.Logger) echo.MiddlewareFunc {
+	if log == nil {
+		log = zap.L()
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			timeout, ok := timeouts[c.Path()]
+			if !ok {
+				timeout = defaultTimeout
+			}
+			if timeout <= 0 {
+				return next(c)
+			}
+
+			ctx, cancel := context.WithTimeout(c.Request().Context(), timeout)
+			defer cancel()
+			c.SetRequest(c.Request().WithContext(ctx))
+
+			err := next(c)
+			if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return err
+			}
+
+			log.Warn("Route timeout exceeded",
+				zap.String("route", c.Path()),
+				zap.String("timeout", timeout.String()),
+				zap.String("method", c.Request().Method),
+				zap.String("request_id", GetRequestIDFromContext(ctx)),
+				zap.String("trace_id", GetTraceIDFromContext(ctx)),
+			)
+			if err == nil && c.Response().Committed {
+				return nil
+			}
+			return echo.ErrServiceUnavailable.WithInternal(ctx.Err())
+		}
+	}
+}
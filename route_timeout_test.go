@@ -0,0 +1,64 @@
+package echomiddleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func newRouteTimeoutEcho(t *testing.T) (*echo.Echo, *observer.ObservedLogs) {
+	t.Helper()
+
+	core, obs := observer.New(zapcore.InfoLevel)
+	e := echo.New()
+	e.Use(RouteTimeout(map[string]time.Duration{
+		"/reports/:id": 10 * time.Millisecond,
+	}, time.Second, zap.New(core)))
+
+	slow := func(c echo.Context) error {
+		select {
+		case <-c.Request().Context().Done():
+			return c.Request().Context().Err()
+		case <-time.After(200 * time.Millisecond):
+			return c.String(http.StatusOK, "done")
+		}
+	}
+	e.GET("/reports/:id", slow)
+	e.GET("/fast", func(c echo.Context) error {
+		return c.String(http.StatusOK, "fast")
+	})
+	return e, obs
+}
+
+func TestRouteTimeoutRouteSpecificDeadline(t *testing.T) {
+	e, obs := newRouteTimeoutEcho(t)
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/reports/42", nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	entries := obs.All()
+	require.Len(t, entries, 1)
+	assert.Equal(t, "Route timeout exceeded", entries[0].Message)
+	assert.Equal(t, "/reports/:id", entries[0].ContextMap()["route"])
+	assert.Equal(t, "10ms", entries[0].ContextMap()["timeout"])
+}
+
+func TestRouteTimeoutDefaultDeadlineNotExceeded(t *testing.T) {
+	e, obs := newRouteTimeoutEcho(t)
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/fast", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "fast", rec.Body.String())
+	assert.Zero(t, obs.Len())
+}
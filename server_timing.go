@@ -0,0 +1,48 @@
+package echomiddleware
+
+import (
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// serverTimingFields parses Server-Timing header values such as `upstream;dur=42, db;dur=3.5`
+// into <name>_ms fields. Metrics without a valid dur parameter are skipped.
+func serverTimingFields(values []string) []zapcore.Field {
+	var fields []zapcore.Field
+	for _, value := range values {
+		for _, metric := range strings.Split(value, ",") {
+			params := strings.Split(metric, ";")
+			name := serverTimingName(params[0])
+			if name == "" {
+				continue
+			}
+			for _, param := range params[1:] {
+				key, raw, found := strings.Cut(strings.TrimSpace(param), "=")
+				if !found || !strings.EqualFold(strings.TrimSpace(key), "dur") {
+					continue
+				}
+				duration, err := strconv.ParseFloat(strings.Trim(strings.TrimSpace(raw), `"`), 64)
+				if err != nil {
+					continue
+				}
+				fields = append(fields, zap.Float64(name+"_ms", duration))
+				break
+			}
+		}
+	}
+	return fields
+}
+
+// serverTimingName lowercases a metric name and replaces characters other than letters and digits
+func serverTimingName(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, name)
+}
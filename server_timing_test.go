@@ -0,0 +1,47 @@
+package echomiddleware
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestServerTimingFields(t *testing.T) {
+	fields := zapFieldsToMap(serverTimingFields([]string{
+		`upstream;dur=42, cache;desc="Cache Read";dur=1.5`,
+		`db-primary;dur="3"`,
+		`missed, broken;dur=abc, ;dur=1, total;desc=x`,
+	}))
+
+	assert.Equal(t, map[string]interface{}{
+		"upstream_ms":   float64(42),
+		"cache_ms":      1.5,
+		"db_primary_ms": float64(3),
+	}, fields)
+}
+
+func TestZapLoggerParseServerTiming(t *testing.T) {
+	_, c, _ := newTestContext(t, http.MethodGet, "/test/123", "")
+
+	core, obs := observer.New(zapcore.InfoLevel)
+	sink := NewRingBufferSink(1)
+	middleware := ZapLoggerWithConfig(ZapLoggerConfig{Logger: zap.New(core), Sink: sink, ParseServerTiming: true})
+	handler := middleware(func(c echo.Context) error {
+		c.Response().Header().Set("Server-Timing", "upstream;dur=42")
+		return c.NoContent(http.StatusOK)
+	})
+
+	require.NoError(t, handler(c))
+	require.Len(t, obs.All(), 1)
+	assert.Equal(t, float64(42), obs.All()[0].ContextMap()["upstream_ms"])
+
+	require.Eventually(t, func() bool { return len(sink.Snapshot()) == 1 }, time.Second, time.Millisecond)
+	assert.Equal(t, float64(42), sink.Snapshot()[0]["upstream_ms"])
+}
@@ -0,0 +1,107 @@
+package echomiddleware
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// LogSink persists the access log documents produced by ZapLogger
+type LogSink interface {
+	Insert(ctx context.Context, document map[string]interface{}) error
+}
+
+// mongoSink persists documents to a MongoDB collection
+type mongoSink struct {
+	collection *mongo.Collection
+}
+
+func (s mongoSink) Insert(ctx context.Context, document map[string]interface{}) error {
+	return mongoInsertFunc(ctx, s.collection, document)
+}
+
+// mongoDisabled is set while MongoDB persistence is switched off with SetMongoEnabled
+var mongoDisabled atomic.Bool
+
+// SetMongoEnabled switches MongoDB persistence on or off at runtime for every ZapLogger, e.g. to relieve
+// the database during an incident. While disabled, entries are still logged through zap but not inserted.
+// Other sinks are unaffected. Persistence is enabled by default.
+func SetMongoEnabled(enabled bool) {
+	mongoDisabled.Store(!enabled)
+}
+
+// MongoEnabled reports whether MongoDB persistence is enabled
+func MongoEnabled() bool {
+	return !mongoDisabled.Load()
+}
+
+// droppedInserts counts the entries dropped because ZapLoggerConfig.MaxConcurrentInserts was reached
+var droppedInserts atomic.Uint64
+
+// DroppedInserts returns the number of entries every ZapLogger dropped instead of persisting because
+// ZapLoggerConfig.MaxConcurrentInserts insert goroutines were already in flight
+func DroppedInserts() uint64 {
+	return droppedInserts.Load()
+}
+
+// acquireInsertSlot reserves one of slots without blocking, counting a drop when none is free.
+// A nil slots is unbounded.
+func acquireInsertSlot(slots chan struct{}) bool {
+	if slots == nil {
+		return true
+	}
+	select {
+	case slots <- struct{}{}:
+		return true
+	default:
+		droppedInserts.Add(1)
+		return false
+	}
+}
+
+func releaseInsertSlot(slots chan struct{}) {
+	if slots != nil {
+		<-slots
+	}
+}
+
+// collectionInitialized reports whether collection was obtained from a connected client through
+// Database.Collection. mongo.Collection exposes no client accessor, so the usable state is inferred from
+// its name and database, both unset on a zero &mongo.Collection{} whose InsertOne would dereference a nil client.
+func collectionInitialized(collection *mongo.Collection) bool {
+	return collection != nil && collection.Name() != "" && collection.Database() != nil
+}
+
+// TTLIndexModel returns an index that expires persisted documents ttl after their date field,
+// e.g. TTLIndexModel("created_at", 30*24*time.Hour) for the default ZapLoggerConfig.DateField
+func TTLIndexModel(field string, ttl time.Duration) mongo.IndexModel {
+	return mongo.IndexModel{
+		Keys:    bson.D{{Key: field, Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(int32(ttl / time.Second)),
+	}
+}
+
+var (
+	baseContextMu sync.RWMutex
+	baseContext   = context.Background()
+)
+
+// Init sets the base context that sink inserts derive from.
+// Cancel it on shutdown, e.g. on SIGTERM, so pending inserts abort promptly
+// instead of blocking until their timeout.
+func Init(ctx context.Context) {
+	baseContextMu.Lock()
+	defer baseContextMu.Unlock()
+	baseContext = ctx
+}
+
+func insertBaseContext() context.Context {
+	baseContextMu.RLock()
+	defer baseContextMu.RUnlock()
+	return baseContext
+}
@@ -0,0 +1,254 @@
+package echomiddleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type blockingSink struct {
+	done chan error
+}
+
+func (s blockingSink) Insert(ctx context.Context, _ map[string]interface{}) error {
+	<-ctx.Done()
+	s.done <- ctx.Err()
+	return ctx.Err()
+}
+
+func TestInitCancelAbortsPendingInserts(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	Init(ctx)
+	t.Cleanup(func() { Init(context.Background()) })
+
+	_, c, _ := newTestContext(t, http.MethodGet, "/test/123", "")
+
+	sink := blockingSink{done: make(chan error, 1)}
+	middleware := ZapLoggerWithConfig(ZapLoggerConfig{Logger: zap.NewNop(), Sink: sink})
+	handler := middleware(func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	require.NoError(t, handler(c))
+	cancel()
+
+	select {
+	case err := <-sink.done:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(time.Second):
+		t.Fatal("insert was not aborted by the cancelled base context")
+	}
+}
+
+func TestZapLoggerPersistsBSONDate(t *testing.T) {
+	tests := []struct {
+		name      string
+		dateField string
+		expected  string
+	}{
+		{name: "default", dateField: "", expected: "created_at"},
+		{name: "custom", dateField: "logged_at", expected: "logged_at"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, c, _ := newTestContext(t, http.MethodGet, "/test/123", "")
+
+			sink := NewRingBufferSink(1)
+			middleware := ZapLoggerWithConfig(ZapLoggerConfig{Logger: zap.NewNop(), Sink: sink, DateField: tc.dateField})
+			handler := middleware(func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			})
+
+			before := time.Now().Add(-time.Second)
+			require.NoError(t, handler(c))
+			require.Eventually(t, func() bool { return len(sink.Snapshot()) == 1 }, time.Second, time.Millisecond)
+
+			document := sink.Snapshot()[0]
+			date, ok := document[tc.expected].(primitive.DateTime)
+			require.True(t, ok, "expected a primitive.DateTime under %q", tc.expected)
+			assert.True(t, date.Time().After(before))
+			assert.Equal(t, date.Time().Unix(), document["timestamp"])
+		})
+	}
+}
+
+func TestTTLIndexModel(t *testing.T) {
+	model := TTLIndexModel("created_at", 7*24*time.Hour)
+
+	assert.Equal(t, bson.D{{Key: "created_at", Value: 1}}, model.Keys)
+	require.NotNil(t, model.Options)
+	require.NotNil(t, model.Options.ExpireAfterSeconds)
+	assert.Equal(t, int32(7*24*60*60), *model.Options.ExpireAfterSeconds)
+}
+
+type recordingSink struct {
+	err       error
+	documents []map[string]interface{}
+}
+
+func (s *recordingSink) Insert(_ context.Context, document map[string]interface{}) error {
+	s.documents = append(s.documents, document)
+	return s.err
+}
+
+func TestZapLoggerSyncInsert(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		_, c, _ := newTestContext(t, http.MethodGet, "/test/123", "")
+
+		sink := &recordingSink{}
+		middleware := ZapLoggerWithConfig(ZapLoggerConfig{Logger: zap.NewNop(), Sink: sink, SyncInsert: true, FailOnInsertError: true})
+		handler := middleware(func(c echo.Context) error {
+			return c.String(http.StatusOK, "ok")
+		})
+
+		require.NoError(t, handler(c))
+		require.Len(t, sink.documents, 1)
+		assert.Equal(t, "ok", sink.documents[0]["response"])
+	})
+
+	t.Run("failure-fails-request", func(t *testing.T) {
+		_, c, _ := newTestContext(t, http.MethodGet, "/test/123", "")
+
+		core, obs := observer.New(zapcore.InfoLevel)
+		sink := &recordingSink{err: errors.New("insert failed")}
+		middleware := ZapLoggerWithConfig(ZapLoggerConfig{Logger: zap.New(core), Sink: sink, SyncInsert: true, FailOnInsertError: true})
+		handler := middleware(func(c echo.Context) error {
+			return c.String(http.StatusOK, "ok")
+		})
+
+		require.EqualError(t, handler(c), "insert failed")
+		require.Len(t, sink.documents, 1)
+		assert.Equal(t, 1, obs.FilterMessage("Error while inserting log to mongo").Len())
+	})
+
+	t.Run("failure-logged-only", func(t *testing.T) {
+		_, c, _ := newTestContext(t, http.MethodGet, "/test/123", "")
+
+		core, obs := observer.New(zapcore.InfoLevel)
+		sink := &recordingSink{err: errors.New("insert failed")}
+		middleware := ZapLoggerWithConfig(ZapLoggerConfig{Logger: zap.New(core), Sink: sink, SyncInsert: true})
+		handler := middleware(func(c echo.Context) error {
+			return c.String(http.StatusOK, "ok")
+		})
+
+		require.NoError(t, handler(c))
+		assert.Equal(t, 1, obs.FilterMessage("Error while inserting log to mongo").Len())
+	})
+}
+
+func TestSetMongoEnabledStopsInserts(t *testing.T) {
+	originalInsert := mongoInsertFunc
+	t.Cleanup(func() {
+		mongoInsertFunc = originalInsert
+		SetMongoEnabled(true)
+	})
+
+	var inserts int
+	mongoInsertFunc = func(ctx context.Context, collection *mongo.Collection, document interface{}) error {
+		inserts++
+		return nil
+	}
+
+	core, obs := observer.New(zapcore.InfoLevel)
+	middleware := ZapLoggerWithConfig(ZapLoggerConfig{Logger: zap.New(core), Collection: newTestCollection(t), SyncInsert: true})
+	handler := middleware(func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+	serve := func() {
+		_, c, _ := newTestContext(t, http.MethodGet, "/test/123", "")
+		require.NoError(t, handler(c))
+	}
+
+	serve()
+	SetMongoEnabled(false)
+	assert.False(t, MongoEnabled())
+	serve()
+	SetMongoEnabled(true)
+	serve()
+
+	assert.Equal(t, 2, inserts)
+	assert.Len(t, obs.All(), 3)
+}
+
+func TestZapLoggerPersistSampledOnly(t *testing.T) {
+	sampled := testSpanContext()
+	unsampled := sampled.WithTraceFlags(0)
+
+	tests := []struct {
+		name        string
+		spanContext trace.SpanContext
+		persisted   int
+	}{
+		{name: "sampled", spanContext: sampled, persisted: 1},
+		{name: "unsampled", spanContext: unsampled, persisted: 0},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, c, _ := newTestContext(t, http.MethodGet, "/test/123", "")
+			c.SetRequest(c.Request().WithContext(trace.ContextWithSpanContext(c.Request().Context(), tc.spanContext)))
+
+			core, obs := observer.New(zapcore.InfoLevel)
+			sink := &recordingSink{}
+			middleware := ZapLoggerWithConfig(ZapLoggerConfig{Logger: zap.New(core), Sink: sink, SyncInsert: true, PersistSampledOnly: true})
+			handler := middleware(func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			})
+
+			require.NoError(t, handler(c))
+			assert.Len(t, obs.All(), 1)
+			assert.Len(t, sink.documents, tc.persisted)
+		})
+	}
+}
+
+// gatedSink blocks every insert until release is closed
+type gatedSink struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (s gatedSink) Insert(context.Context, map[string]interface{}) error {
+	s.started <- struct{}{}
+	<-s.release
+	return nil
+}
+
+func TestZapLoggerMaxConcurrentInsertsDrops(t *testing.T) {
+	sink := gatedSink{started: make(chan struct{}, 3), release: make(chan struct{})}
+	t.Cleanup(func() { close(sink.release) })
+
+	middleware := ZapLoggerWithConfig(ZapLoggerConfig{Logger: zap.NewNop(), Sink: sink, MaxConcurrentInserts: 1})
+	handler := middleware(func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	before := DroppedInserts()
+	_, c, _ := newTestContext(t, http.MethodGet, "/test/123", "")
+	require.NoError(t, handler(c))
+	select {
+	case <-sink.started:
+	case <-time.After(time.Second):
+		t.Fatal("first insert did not start")
+	}
+
+	for i := 0; i < 2; i++ {
+		_, c, _ := newTestContext(t, http.MethodGet, "/test/123", "")
+		require.NoError(t, handler(c))
+	}
+
+	assert.Equal(t, before+2, DroppedInserts())
+	assert.Empty(t, sink.started)
+}
@@ -0,0 +1,34 @@
+package echomiddleware
+
+import (
+	"sync"
+
+	"github.com/labstack/echo/v4"
+)
+
+var (
+	skipPathsMu sync.RWMutex
+	skipPaths   = map[string]struct{}{}
+)
+
+// RegisterSkipPath registers a path that ZapLogger and BodyDump never log, e.g. a metrics endpoint
+// mounted by a library. The path matches either the route template or the request URL path.
+// It is safe for concurrent use.
+func RegisterSkipPath(path string) {
+	skipPathsMu.Lock()
+	defer skipPathsMu.Unlock()
+	skipPaths[path] = struct{}{}
+}
+
+func isSkipPath(c echo.Context) bool {
+	skipPathsMu.RLock()
+	defer skipPathsMu.RUnlock()
+	if len(skipPaths) == 0 {
+		return false
+	}
+	if _, ok := skipPaths[c.Path()]; ok {
+		return true
+	}
+	_, ok := skipPaths[c.Request().URL.Path]
+	return ok
+}
@@ -0,0 +1,87 @@
+package echomiddleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func registerTestSkipPath(t *testing.T, path string) {
+	t.Helper()
+	RegisterSkipPath(path)
+	t.Cleanup(func() {
+		skipPathsMu.Lock()
+		defer skipPathsMu.Unlock()
+		delete(skipPaths, path)
+	})
+}
+
+func TestRegisteredSkipPathSkipsZapLoggerAndBodyDump(t *testing.T) {
+	registerTestSkipPath(t, "/metrics")
+	viper.Set("ENVIRONMENT", "development")
+	t.Cleanup(func() {
+		viper.Set("ENVIRONMENT", "")
+	})
+
+	core, obs := observer.New(zapcore.InfoLevel)
+	logger := zap.New(core)
+	undo := zap.ReplaceGlobals(logger)
+	t.Cleanup(func() { undo() })
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	c := e.NewContext(req, httptest.NewRecorder())
+	c.SetPath("/metrics")
+
+	handler := ZapLogger(logger, nil)(func(c echo.Context) error {
+		return c.String(http.StatusOK, "metrics")
+	})
+	require.NoError(t, handler(c))
+	BodyDump(c, nil, []byte("metrics"))
+
+	assert.Zero(t, obs.Len())
+}
+
+func TestUnregisteredPathIsLogged(t *testing.T) {
+	registerTestSkipPath(t, "/metrics")
+
+	_, c, _ := newTestContext(t, http.MethodGet, "/test/123", "")
+
+	core, obs := observer.New(zapcore.InfoLevel)
+	handler := ZapLogger(zap.New(core), nil)(func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	require.NoError(t, handler(c))
+	assert.Equal(t, 1, obs.Len())
+}
+
+func TestRegisterSkipPathConcurrent(t *testing.T) {
+	e := echo.New()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/concurrent", nil), httptest.NewRecorder())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			registerTestSkipPath(t, "/concurrent")
+		}()
+		go func() {
+			defer wg.Done()
+			_ = isSkipPath(c)
+		}()
+	}
+	wg.Wait()
+
+	assert.True(t, isSkipPath(c))
+}
@@ -0,0 +1,99 @@
+//go:build !windows && !plan9
+
+package echomiddleware
+
+import (
+	"context"
+	"encoding/json"
+	"log/syslog"
+)
+
+// SyslogSinkConfig defines the config for SyslogSink.
+type SyslogSinkConfig struct {
+	// Network and Addr select the syslog endpoint, e.g. "udp" and "logs.internal:514".
+	// Empty values connect to the local syslog daemon.
+	Network string
+	Addr    string
+
+	// Facility of every message, e.g. syslog.LOG_LOCAL0. Defaults to syslog.LOG_USER.
+	Facility syslog.Priority
+
+	// Tag prefixes every message. Defaults to the process name.
+	Tag string
+
+	// Severity maps the entry status to a syslog severity. Defaults to SyslogSeverity.
+	Severity func(status int) syslog.Priority
+}
+
+// SyslogSink is a LogSink that writes every document as a JSON syslog message.
+type SyslogSink struct {
+	writer   *syslog.Writer
+	severity func(status int) syslog.Priority
+}
+
+// NewSyslogSink connects to the syslog endpoint described by config.
+func NewSyslogSink(config SyslogSinkConfig) (*SyslogSink, error) {
+	if config.Facility == 0 {
+		config.Facility = syslog.LOG_USER
+	}
+	if config.Severity == nil {
+		config.Severity = SyslogSeverity
+	}
+	writer, err := syslog.Dial(config.Network, config.Addr, config.Facility|syslog.LOG_INFO, config.Tag)
+	if err != nil {
+		return nil, err
+	}
+	return &SyslogSink{writer: writer, severity: config.Severity}, nil
+}
+
+// SyslogSeverity maps 5xx statuses to LOG_ERR, 4xx statuses to LOG_WARNING, and others to LOG_INFO.
+func SyslogSeverity(status int) syslog.Priority {
+	switch {
+	case status >= 500:
+		return syslog.LOG_ERR
+	case status >= 400:
+		return syslog.LOG_WARNING
+	default:
+		return syslog.LOG_INFO
+	}
+}
+
+// Insert writes the document with the severity mapped from its status
+func (s *SyslogSink) Insert(_ context.Context, document map[string]interface{}) error {
+	message, err := json.Marshal(document)
+	if err != nil {
+		return err
+	}
+
+	var status int
+	switch value := document["status"].(type) {
+	case int64:
+		status = int(value)
+	case int:
+		status = value
+	}
+
+	switch s.severity(status) {
+	case syslog.LOG_EMERG:
+		return s.writer.Emerg(string(message))
+	case syslog.LOG_ALERT:
+		return s.writer.Alert(string(message))
+	case syslog.LOG_CRIT:
+		return s.writer.Crit(string(message))
+	case syslog.LOG_ERR:
+		return s.writer.Err(string(message))
+	case syslog.LOG_WARNING:
+		return s.writer.Warning(string(message))
+	case syslog.LOG_NOTICE:
+		return s.writer.Notice(string(message))
+	case syslog.LOG_DEBUG:
+		return s.writer.Debug(string(message))
+	default:
+		return s.writer.Info(string(message))
+	}
+}
+
+// Close closes the connection to the syslog endpoint
+func (s *SyslogSink) Close() error {
+	return s.writer.Close()
+}
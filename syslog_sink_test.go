@@ -0,0 +1,56 @@
+//go:build !windows && !plan9
+
+package echomiddleware
+
+import (
+	"log/syslog"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestSyslogSinkWritesMappedSeverity(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	sink, err := NewSyslogSink(SyslogSinkConfig{
+		Network:  "udp",
+		Addr:     conn.LocalAddr().String(),
+		Facility: syslog.LOG_LOCAL0,
+		Tag:      "access",
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = sink.Close() })
+
+	_, c, _ := newTestContext(t, http.MethodGet, "/test/123", "")
+	middleware := ZapLoggerWithConfig(ZapLoggerConfig{Logger: zap.NewNop(), Sink: sink, SyncInsert: true})
+	handler := middleware(func(c echo.Context) error {
+		return c.String(http.StatusInternalServerError, "boom")
+	})
+	require.NoError(t, handler(c))
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	buf := make([]byte, 64*1024)
+	n, _, err := conn.ReadFrom(buf)
+	require.NoError(t, err)
+
+	message := string(buf[:n])
+	// LOG_LOCAL0 (16<<3) | LOG_ERR (3)
+	assert.Contains(t, message, "<131>")
+	assert.Contains(t, message, "access[")
+	assert.Contains(t, message, `"status":500`)
+	assert.Contains(t, message, `"response":"boom"`)
+}
+
+func TestSyslogSeverity(t *testing.T) {
+	assert.Equal(t, syslog.LOG_INFO, SyslogSeverity(http.StatusOK))
+	assert.Equal(t, syslog.LOG_WARNING, SyslogSeverity(http.StatusNotFound))
+	assert.Equal(t, syslog.LOG_ERR, SyslogSeverity(http.StatusBadGateway))
+}
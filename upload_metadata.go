@@ -0,0 +1,85 @@
+package echomiddleware
+
+import (
+	"bytes"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"sort"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// uploadMetadataFields describes a request body that is not logged: its declared size and type, and
+// the uploaded filenames. The filename comes from the request Content-Disposition header; multipart
+// filenames are only known when the handler parsed the form, since the body is never read for logging.
+func uploadMetadataFields(req *http.Request) []zapcore.Field {
+	fields := []zapcore.Field{
+		zap.Int64("content_length", req.ContentLength),
+		zap.String("content_type", req.Header.Get("Content-Type")),
+	}
+	if _, params, err := mime.ParseMediaType(req.Header.Get("Content-Disposition")); err == nil && params["filename"] != "" {
+		fields = append(fields, zap.String("filename", params["filename"]))
+	}
+	if req.MultipartForm != nil && len(req.MultipartForm.File) > 0 {
+		var filenames []string
+		for _, headers := range req.MultipartForm.File {
+			for _, header := range headers {
+				filenames = append(filenames, header.Filename)
+			}
+		}
+		sort.Strings(filenames)
+		fields = append(fields, zap.Strings("filenames", filenames))
+	}
+	return fields
+}
+
+// defaultMultipartMaxMemory matches the memory limit Echo uses when handlers parse multipart forms
+const defaultMultipartMaxMemory = 32 << 20
+
+// UploadedFile describes a file part of a logged multipart form
+type UploadedFile struct {
+	Field    string `json:"field" bson:"field"`
+	Filename string `json:"filename" bson:"filename"`
+	Size     int64  `json:"size" bson:"size"`
+}
+
+// multipartFormFields parses a copy of a buffered multipart body and returns the names of its value
+// fields as form_fields and its files as uploaded_files. Parts beyond maxMemory spill to temporary
+// files, which are removed before returning. Unparsable bodies yield no fields.
+func multipartFormFields(body []byte, contentType string, maxMemory int64) []zapcore.Field {
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil || params["boundary"] == "" {
+		return nil
+	}
+	form, err := multipart.NewReader(bytes.NewReader(body), params["boundary"]).ReadForm(maxMemory)
+	if err != nil {
+		return nil
+	}
+	defer func() { _ = form.RemoveAll() }()
+
+	names := make([]string, 0, len(form.Value))
+	for name := range form.Value {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	files := make([]UploadedFile, 0, len(form.File))
+	for field, headers := range form.File {
+		for _, header := range headers {
+			files = append(files, UploadedFile{Field: field, Filename: header.Filename, Size: header.Size})
+		}
+	}
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].Field != files[j].Field {
+			return files[i].Field < files[j].Field
+		}
+		return files[i].Filename < files[j].Filename
+	})
+
+	return []zapcore.Field{
+		zap.Strings("form_fields", names),
+		zap.Any("uploaded_files", files),
+	}
+}
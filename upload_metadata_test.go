@@ -0,0 +1,115 @@
+package echomiddleware
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestZapLoggerMetadataOnlyMultipartUpload(t *testing.T) {
+	var payload bytes.Buffer
+	form := multipart.NewWriter(&payload)
+	part, err := form.CreateFormFile("file", "report.pdf")
+	require.NoError(t, err)
+	_, err = part.Write([]byte("%PDF-1.7 secret contents"))
+	require.NoError(t, err)
+	require.NoError(t, form.Close())
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/upload", bytes.NewReader(payload.Bytes()))
+	req.Header.Set(echo.HeaderContentType, form.FormDataContentType())
+	c := e.NewContext(req, httptest.NewRecorder())
+
+	core, obs := observer.New(zapcore.InfoLevel)
+	sink := &recordingSink{}
+	middleware := ZapLoggerWithConfig(ZapLoggerConfig{
+		Logger:                   zap.New(core),
+		Sink:                     sink,
+		SyncInsert:               true,
+		MetadataOnlyContentTypes: []string{echo.MIMEMultipartForm},
+	})
+	handler := middleware(func(c echo.Context) error {
+		file, err := c.FormFile("file")
+		if err != nil {
+			return err
+		}
+		return c.String(http.StatusCreated, file.Filename)
+	})
+
+	require.NoError(t, handler(c))
+
+	require.Len(t, obs.All(), 1)
+	fields := obs.All()[0].ContextMap()
+	assert.Equal(t, "", fields["body"])
+	assert.Equal(t, int64(payload.Len()), fields["content_length"])
+	assert.Equal(t, form.FormDataContentType(), fields["content_type"])
+	assert.Equal(t, []interface{}{"report.pdf"}, fields["filenames"])
+	require.Len(t, sink.documents, 1)
+	assert.Equal(t, []interface{}{"report.pdf"}, sink.documents[0]["filenames"])
+}
+
+func TestUploadMetadataFieldsFromContentDisposition(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPut, "/upload", bytes.NewReader([]byte("data")))
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Content-Disposition", `attachment; filename="image.png"`)
+
+	enc := zapcore.NewMapObjectEncoder()
+	for _, field := range uploadMetadataFields(req) {
+		field.AddTo(enc)
+	}
+	assert.Equal(t, map[string]interface{}{
+		"content_length": int64(4),
+		"content_type":   "application/octet-stream",
+		"filename":       "image.png",
+	}, enc.Fields)
+}
+
+func TestZapLoggerLogsMultipartFields(t *testing.T) {
+	var payload bytes.Buffer
+	form := multipart.NewWriter(&payload)
+	require.NoError(t, form.WriteField("title", "Q3 report"))
+	require.NoError(t, form.WriteField("tags", "finance"))
+	part, err := form.CreateFormFile("attachment", "report.pdf")
+	require.NoError(t, err)
+	_, err = part.Write([]byte("%PDF-1.7 secret contents"))
+	require.NoError(t, err)
+	require.NoError(t, form.Close())
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/upload", bytes.NewReader(payload.Bytes()))
+	req.Header.Set(echo.HeaderContentType, form.FormDataContentType())
+	c := e.NewContext(req, httptest.NewRecorder())
+
+	core, obs := observer.New(zapcore.InfoLevel)
+	sink := &recordingSink{}
+	middleware := ZapLoggerWithConfig(ZapLoggerConfig{Logger: zap.New(core), Sink: sink, SyncInsert: true, LogMultipartFields: true})
+	handler := middleware(func(c echo.Context) error {
+		file, err := c.FormFile("attachment")
+		if err != nil {
+			return err
+		}
+		return c.String(http.StatusCreated, c.FormValue("title")+" "+file.Filename)
+	})
+
+	require.NoError(t, handler(c))
+	assert.Equal(t, http.StatusCreated, c.Response().Status)
+
+	require.Len(t, obs.All(), 1)
+	fields := obs.All()[0].ContextMap()
+	assert.Equal(t, "", fields["body"])
+	assert.Equal(t, "Q3 report report.pdf", fields["response"])
+	assert.Equal(t, []interface{}{"tags", "title"}, fields["form_fields"])
+	assert.Equal(t, []UploadedFile{{Field: "attachment", Filename: "report.pdf", Size: 24}}, fields["uploaded_files"])
+	require.Len(t, sink.documents, 1)
+	assert.Equal(t, []interface{}{"tags", "title"}, sink.documents[0]["form_fields"])
+	assert.Equal(t, []UploadedFile{{Field: "attachment", Filename: "report.pdf", Size: 24}}, sink.documents[0]["uploaded_files"])
+}
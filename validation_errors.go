@@ -0,0 +1,34 @@
+package echomiddleware
+
+import (
+	"errors"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// FieldViolation describes a single failed validation rule
+type FieldViolation struct {
+	Field   string `json:"field" bson:"field"`
+	Message string `json:"message" bson:"message"`
+}
+
+// ValidationError is implemented by handler errors carrying field-level validation failures.
+// ZapLogger logs the violations of such errors, including wrapped ones, as validation_errors.
+type ValidationError interface {
+	error
+	Violations() []FieldViolation
+}
+
+// validationErrorFields returns the validation_errors field when err wraps a ValidationError
+func validationErrorFields(err error) []zapcore.Field {
+	var validationErr ValidationError
+	if !errors.As(err, &validationErr) {
+		return nil
+	}
+	violations := validationErr.Violations()
+	if len(violations) == 0 {
+		return nil
+	}
+	return []zapcore.Field{zap.Any("validation_errors", violations)}
+}
@@ -0,0 +1,47 @@
+package echomiddleware
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type testValidationError []FieldViolation
+
+func (e testValidationError) Error() string { return "validation failed" }
+
+func (e testValidationError) Violations() []FieldViolation { return e }
+
+func TestZapLoggerLogsValidationErrors(t *testing.T) {
+	_, c, _ := newTestContext(t, http.MethodPost, "/test/123", `{"email":""}`)
+
+	core, obs := observer.New(zapcore.InfoLevel)
+	middleware := ZapLogger(zap.New(core), nil)
+	handler := middleware(func(c echo.Context) error {
+		return fmt.Errorf("create user: %w", testValidationError{
+			{Field: "email", Message: "is required"},
+			{Field: "age", Message: "must be positive"},
+		})
+	})
+
+	require.NoError(t, handler(c))
+
+	require.Len(t, obs.All(), 1)
+	assert.Equal(t, []FieldViolation{
+		{Field: "email", Message: "is required"},
+		{Field: "age", Message: "must be positive"},
+	}, obs.All()[0].ContextMap()["validation_errors"])
+}
+
+func TestValidationErrorFieldsIgnoresOtherErrors(t *testing.T) {
+	assert.Nil(t, validationErrorFields(nil))
+	assert.Nil(t, validationErrorFields(fmt.Errorf("boom")))
+	assert.Nil(t, validationErrorFields(testValidationError{}))
+}